@@ -2,9 +2,14 @@ package dent
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"entgo.io/ent/dialect"
 	"entgo.io/ent/dialect/sql"
@@ -21,6 +26,8 @@ type Client struct {
 	// Dynamic is the client for interacting with the Dynamic builders.
 	// Dynamic *DynamicClient
 	tmap map[string]*schema.Table
+	// relations of the registered tables, by their table and name.
+	rmap map[string]map[string]*Relation
 }
 
 // NewClient creates a new client configured with the given options.
@@ -34,7 +41,14 @@ func NewClient(opts ...Option) *Client {
 
 func (c *Client) init() {
 	c.Schema = NewSchema(c.driver)
-	c.tmap = make(map[string]*schema.Table)
+	// Clients derived from another client (e.g. debug or transactional
+	// clients) share the registered tables with it.
+	if c.tmap == nil {
+		c.tmap = make(map[string]*schema.Table)
+	}
+	if c.rmap == nil {
+		c.rmap = make(map[string]map[string]*Relation)
+	}
 	// c.Dynamic = NewDynamicClient(c.config)
 }
 
@@ -105,11 +119,16 @@ func (c *Client) Debug() *Client {
 	}
 	cfg := c.config
 	cfg.driver = dialect.Debug(c.driver, c.log)
-	client := &Client{config: cfg}
+	client := &Client{config: cfg, tmap: c.tmap, rmap: c.rmap}
 	client.init()
 	return client
 }
 
+// Dialect returns the dialect of the database of the client (e.g. dialect.MySQL).
+func (c *Client) Dialect() string {
+	return c.driver.Dialect()
+}
+
 // Close closes the database connection and prevents new queries from starting.
 func (c *Client) Close() error {
 	return c.driver.Close()
@@ -127,6 +146,16 @@ func (c *Client) DeleteTable(name string) {
 	delete(c.tmap, name)
 }
 
+// Tables returns the registered tables, sorted by their names.
+func (c *Client) Tables() []*schema.Table {
+	tables := make([]*schema.Table, 0, len(c.tmap))
+	for _, t := range c.tmap {
+		tables = append(tables, t)
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	return tables
+}
+
 type Table struct {
 	config
 	*schema.Table
@@ -221,7 +250,12 @@ func (c *Table) GetColumns() []string {
 func (c *Table) scanValues(columns []string) ([]interface{}, error) {
 	values := make([]interface{}, len(columns))
 	for i, v := range columns {
-		if val, ok := c.Column(v); ok {
+		val, ok := c.Column(v)
+		if !ok {
+			// Columns of joined tables, prefixed with the table name.
+			val, ok = c.joinedColumn(v)
+		}
+		if ok {
 			switch val.Type {
 			case field.TypeBool:
 				values[i] = new(sql.NullBool)
@@ -245,6 +279,126 @@ func (c *Table) scanValues(columns []string) ([]interface{}, error) {
 	return values, nil
 }
 
+// joinedColumn returns the column of a registered table by its
+// prefixed name. For example, "users.name".
+func (c *Table) joinedColumn(name string) (*schema.Column, bool) {
+	i := strings.IndexByte(name, '.')
+	if i == -1 || c.client == nil {
+		return nil, false
+	}
+	t, ok := c.client.tmap[name[:i]]
+	if !ok {
+		return nil, false
+	}
+	return t.Column(name[i+1:])
+}
+
+// ParseValue converts the textual representation of a value to the Go type
+// of the given column. Empty strings are converted to nil for nullable,
+// non-string columns.
+func (c *Table) ParseValue(column, s string) (interface{}, error) {
+	col, ok := c.Column(column)
+	if !ok {
+		return nil, &ValidationError{Name: column, err: fmt.Errorf("ent: invalid field %q for table %q", column, c.Name)}
+	}
+	if s == "" && col.Nullable && col.Type != field.TypeString {
+		return nil, nil
+	}
+	var (
+		v   interface{}
+		err error
+	)
+	switch col.Type {
+	case field.TypeBool:
+		v, err = strconv.ParseBool(s)
+	case field.TypeTime:
+		v, err = parseTime(s)
+	case field.TypeJSON:
+		if !json.Valid([]byte(s)) {
+			err = errors.New("invalid JSON value")
+		}
+		v = []byte(s)
+	case field.TypeBytes:
+		v = []byte(s)
+	case field.TypeEnum:
+		v = s
+		if len(col.Enums) > 0 {
+			err = fmt.Errorf("value is not one of %v", col.Enums)
+			for _, e := range col.Enums {
+				if e == s {
+					err = nil
+				}
+			}
+		}
+	case field.TypeUUID, field.TypeString, field.TypeOther:
+		v = s
+	case field.TypeInt8, field.TypeInt16, field.TypeInt32, field.TypeInt, field.TypeInt64:
+		v, err = strconv.ParseInt(s, 10, 64)
+	case field.TypeUint8, field.TypeUint16, field.TypeUint32, field.TypeUint, field.TypeUint64:
+		v, err = strconv.ParseUint(s, 10, 64)
+	case field.TypeFloat32, field.TypeFloat64:
+		v, err = strconv.ParseFloat(s, 64)
+	default:
+		err = fmt.Errorf("unexpected column type %s", col.Type)
+	}
+	if err != nil {
+		return nil, &ValidationError{Name: column, err: fmt.Errorf("ent: invalid value %q for field %q: %w", s, column, err)}
+	}
+	return v, nil
+}
+
+// parseTime parses s using the time layouts accepted by ParseValue.
+func parseTime(s string) (t time.Time, err error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err = time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return t, err
+}
+
+// withTx calls fn with a client that is bound to a new transaction, and commits it
+// if fn succeeds. If the client already runs in a transaction, fn is called with the
+// client itself, and the transaction is left to its owner.
+func (c *Client) withTx(ctx context.Context, fn func(*Client) error) error {
+	if _, ok := c.driver.(*txDriver); ok {
+		return fn(c)
+	}
+	tx, err := c.Tx(ctx)
+	if err != nil {
+		return err
+	}
+	if err := fn(tx.Client()); err != nil {
+		if rerr := tx.Rollback(); rerr != nil {
+			err = fmt.Errorf("%w: rolling back transaction: %v", err, rerr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// withTx calls fn with a copy of the table that is bound to a new transaction, and
+// commits it if fn succeeds. If the table already runs in a transaction, fn is
+// called with the table itself, and the transaction is left to its owner.
+func (c *Table) withTx(ctx context.Context, fn func(*Table) error) error {
+	if _, ok := c.driver.(*txDriver); ok {
+		return fn(c)
+	}
+	tx, err := newTx(ctx, c.driver)
+	if err != nil {
+		return fmt.Errorf("ent: starting a transaction: %w", err)
+	}
+	t := *c
+	t.driver = tx
+	if err := fn(&t); err != nil {
+		if rerr := tx.tx.Rollback(); rerr != nil {
+			err = fmt.Errorf("%w: rolling back transaction: %v", err, rerr)
+		}
+		return err
+	}
+	return tx.tx.Commit()
+}
+
 // Hooks returns the client hooks.
 func (c *Table) Clone() *Table {
 	var t Table
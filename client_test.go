@@ -2,13 +2,39 @@ package dent
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
 	"testing"
 
 	"entgo.io/ent/dialect/sql/schema"
 	"entgo.io/ent/schema/field"
 	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/mattn/go-sqlite3"
 )
 
+// testDBs counts the databases opened by openTest.
+var testDBs int32
+
+// openTest opens a client to a new in-memory SQLite database,
+// and creates and registers the given tables.
+func openTest(t *testing.T, tables ...*schema.Table) *Client {
+	t.Helper()
+	name := fmt.Sprintf("%s_%d", strings.NewReplacer("/", "_", " ", "_").Replace(t.Name()), atomic.AddInt32(&testDBs, 1))
+	client, err := Open("sqlite3", "file:"+name+"?mode=memory&cache=shared&_fk=1")
+	if err != nil {
+		t.Fatalf("failed opening connection to sqlite: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	for _, table := range tables {
+		if err := client.Schema.Create(context.Background(), table); err != nil {
+			t.Fatalf("failed creating table %q: %v", table.Name, err)
+		}
+		client.AddTable(table)
+	}
+	return client
+}
+
 func TestNewClient(t *testing.T) {
 	dclient, err := Open("mysql", "root:pass@tcp(localhost:3306)/test")
 	if err != nil {
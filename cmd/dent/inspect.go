@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"entgo.io/ent/dialect/sql/schema"
+	"github.com/go-kenka/dent"
+)
+
+// runInspect prints the definitions of the tables that exist in the database.
+func runInspect(_ context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	tables := e.tables
+	if fs.NArg() > 0 {
+		tables = make([]*schema.Table, 0, fs.NArg())
+		for _, name := range fs.Args() {
+			t, err := e.table(name)
+			if err != nil {
+				return err
+			}
+			tables = append(tables, t.Table)
+		}
+	}
+	switch *format {
+	case "json":
+		// The JSON output can be used as a definition file.
+		return dent.WriteTableDefs(os.Stdout, tables...)
+	case "text":
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		for i, t := range tables {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "%s\n", t.Name)
+			fmt.Fprintln(w, "  COLUMN\tTYPE\tNULLABLE\tUNIQUE\tDEFAULT")
+			for _, def := range dent.NewTableDef(t).Columns {
+				fmt.Fprintf(w, "  %s\t%s\t%t\t%t\t%v\n", def.Name, def.Type, def.Nullable, def.Unique, def.Default)
+			}
+			for _, idx := range t.Indexes {
+				columns := make([]string, 0, len(idx.Columns))
+				for _, c := range idx.Columns {
+					columns = append(columns, c.Name)
+				}
+				kind := "INDEX"
+				if idx.Unique {
+					kind = "UNIQUE INDEX"
+				}
+				fmt.Fprintf(w, "  %s %s (%s)\n", kind, idx.Name, strings.Join(columns, ", "))
+			}
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+}
@@ -0,0 +1,171 @@
+// Command dent is a command-line tool for inspecting, migrating and querying
+// the dynamic tables of a database through the dent client.
+//
+// Usage:
+//
+//	dent [-driver name] [-dsn source] [-schema file] [-debug] <command> [flags] [args]
+//
+// The driver and the data source name default to the DENT_DRIVER and DENT_DSN
+// environment variables. Tables are loaded from the definition file given by
+// -schema, or inspected from the database if no file was provided.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"entgo.io/ent/dialect/sql/schema"
+	"github.com/go-kenka/dent"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// command is a dent subcommand.
+type command struct {
+	name  string
+	usage string
+	run   func(context.Context, *env, []string) error
+}
+
+var commands = []*command{
+	{name: "inspect", usage: "inspect [-format text|json] [table...]", run: runInspect},
+	{name: "migrate", usage: "migrate [-dry-run] [-drop-column] [-drop-index]", run: runMigrate},
+	{name: "query", usage: "query -table name [-where expr]... [-order [-]field]... [-select fields] [-limit n] [-offset n] [-format table|json|csv]", run: runQuery},
+	{name: "create", usage: "create -table name column=value...", run: runCreate},
+	{name: "update", usage: "update -table name [-where expr]... [-all] column=value...", run: runUpdate},
+	{name: "delete", usage: "delete -table name [-where expr]... [-all]", run: runDelete},
+	{name: "import", usage: "import -table name [-format csv|json] [-batch n] file", run: runImport},
+	{name: "export", usage: "export -table name [-where expr]... [-format csv|json] [-o file]", run: runExport},
+}
+
+// env holds the state shared by all commands.
+type env struct {
+	client *dent.Client
+	// defs holds the tables read from the definition file, if provided.
+	defs []*schema.Table
+	// tables holds the tables registered on the client.
+	tables []*schema.Table
+}
+
+// table returns the registered table with the given name.
+func (e *env) table(name string) (*dent.Table, error) {
+	if name == "" {
+		return nil, fmt.Errorf("missing -table flag")
+	}
+	for _, t := range e.tables {
+		if t.Name == name {
+			return e.client.Table(name), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown table %q", name)
+}
+
+func main() {
+	if err := run(context.Background(), os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "dent:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("dent", flag.ContinueOnError)
+	var (
+		driver = fs.String("driver", os.Getenv("DENT_DRIVER"), "database driver: mysql, postgres or sqlite3")
+		dsn    = fs.String("dsn", os.Getenv("DENT_DSN"), "data source name")
+		file   = fs.String("schema", "", "table definition file (JSON)")
+		debug  = fs.Bool("debug", false, "log executed statements")
+	)
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintln(out, "usage: dent [flags] <command> [command flags] [args]")
+		fmt.Fprintln(out, "\nflags:")
+		fs.PrintDefaults()
+		fmt.Fprintln(out, "\ncommands:")
+		for _, c := range commands {
+			fmt.Fprintln(out, "  dent", c.usage)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("missing command")
+	}
+	var cmd *command
+	for _, c := range commands {
+		if c.name == fs.Arg(0) {
+			cmd = c
+		}
+	}
+	if cmd == nil {
+		return fmt.Errorf("unknown command %q", fs.Arg(0))
+	}
+	if *driver == "" || *dsn == "" {
+		return fmt.Errorf("missing -driver or -dsn flag")
+	}
+	var opts []dent.Option
+	if *debug {
+		opts = append(opts, dent.Debug())
+	}
+	client, err := dent.Open(*driver, *dsn, opts...)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	e := &env{client: client}
+	if *file != "" {
+		if e.defs, err = readDefs(*file); err != nil {
+			return err
+		}
+	}
+	if err := e.load(ctx, cmd.name); err != nil {
+		return err
+	}
+	return cmd.run(ctx, e, fs.Args()[1:])
+}
+
+// load registers the tables used by the command on the client.
+func (e *env) load(ctx context.Context, cmd string) error {
+	tables := e.defs
+	// The inspect command always looks at the database itself, all
+	// other commands use the definition file if one was provided.
+	if tables == nil || cmd == "inspect" {
+		inspected, err := e.client.Schema.Inspect(ctx)
+		if err != nil {
+			return fmt.Errorf("inspecting tables: %w", err)
+		}
+		tables = inspected
+	}
+	for _, t := range tables {
+		e.client.AddTable(t)
+	}
+	e.tables = tables
+	return nil
+}
+
+// readDefs reads the table definitions from the given file.
+func readDefs(path string) ([]*schema.Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	defs, err := dent.ReadTableDefs(f)
+	if err != nil {
+		return nil, err
+	}
+	tables := make([]*schema.Table, 0, len(defs))
+	for _, d := range defs {
+		t, err := d.Table()
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, t)
+	}
+	return tables, nil
+}
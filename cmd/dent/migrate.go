@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"entgo.io/ent/dialect/sql/schema"
+	"github.com/go-kenka/dent"
+)
+
+// runMigrate migrates the database to the tables of the definition file.
+func runMigrate(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	var (
+		dryRun     = fs.Bool("dry-run", false, "print the migration plan without applying it")
+		dropColumn = fs.Bool("drop-column", false, "drop columns that are missing from the definitions")
+		dropIndex  = fs.Bool("drop-index", false, "drop indexes that are missing from the definitions")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if e.defs == nil {
+		return fmt.Errorf("migrate requires a definition file (-schema flag)")
+	}
+	opts := []schema.MigrateOption{
+		dent.WithDropColumn(*dropColumn),
+		dent.WithDropIndex(*dropIndex),
+	}
+	if *dryRun {
+		return dent.WriteTo(ctx, e.client.Schema, os.Stdout, e.defs, opts...)
+	}
+	return dent.Create(ctx, e.client.Schema, e.defs, opts...)
+}
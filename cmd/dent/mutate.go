@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/go-kenka/dent"
+)
+
+// runCreate creates a row from "column=value" arguments and prints it.
+func runCreate(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	var (
+		name   = fs.String("table", "", "table to insert into")
+		format = fs.String("format", "table", "output format: table, json or csv")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	t, err := e.table(*name)
+	if err != nil {
+		return err
+	}
+	values, err := parseAssignments(t, fs.Args())
+	if err != nil {
+		return err
+	}
+	create := t.Create()
+	for column, v := range values {
+		create.SetValue(column, v)
+	}
+	node, err := create.Save(ctx)
+	if err != nil {
+		return err
+	}
+	// Reload the row to print the values that were set by the database.
+	if node, err = t.Get(ctx, node.ID); err != nil {
+		return err
+	}
+	return writeRows(os.Stdout, *format, columns(t), []*dent.Dynamic{node})
+}
+
+// runUpdate updates the rows matching the filters with "column=value" arguments.
+func runUpdate(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("update", flag.ContinueOnError)
+	var (
+		where listFlag
+		name  = fs.String("table", "", "table to update")
+		all   = fs.Bool("all", false, "allow updating all rows when no filter is given")
+	)
+	fs.Var(&where, "where", "filter in the form of column<op>value")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if len(where) == 0 && !*all {
+		return fmt.Errorf("update without filters requires the -all flag")
+	}
+	t, err := e.table(*name)
+	if err != nil {
+		return err
+	}
+	ps, err := parseWhere(t, where)
+	if err != nil {
+		return err
+	}
+	values, err := parseAssignments(t, fs.Args())
+	if err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("missing column=value arguments")
+	}
+	update := t.Update().Where(ps...)
+	for column, v := range values {
+		update.SetValue(column, v)
+	}
+	n, err := update.Save(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d rows updated\n", n)
+	return nil
+}
+
+// runDelete deletes the rows matching the filters.
+func runDelete(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("delete", flag.ContinueOnError)
+	var (
+		where listFlag
+		name  = fs.String("table", "", "table to delete from")
+		all   = fs.Bool("all", false, "allow deleting all rows when no filter is given")
+	)
+	fs.Var(&where, "where", "filter in the form of column<op>value")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if len(where) == 0 && !*all {
+		return fmt.Errorf("delete without filters requires the -all flag")
+	}
+	t, err := e.table(*name)
+	if err != nil {
+		return err
+	}
+	ps, err := parseWhere(t, where)
+	if err != nil {
+		return err
+	}
+	n, err := t.Delete().Where(ps...).Exec(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d rows deleted\n", n)
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-kenka/dent"
+)
+
+// writeRows writes the given columns of the nodes to w in the given format.
+func writeRows(w io.Writer, format string, columns []string, nodes []*dent.Dynamic) error {
+	switch format {
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(columns, "\t"))
+		for _, n := range nodes {
+			values := make([]string, len(columns))
+			for i, c := range columns {
+				if values[i] = formatValue(value(n, c)); values[i] == "" {
+					values[i] = "NULL"
+				}
+			}
+			fmt.Fprintln(tw, strings.Join(values, "\t"))
+		}
+		return tw.Flush()
+	case "json":
+		rows := make([]map[string]interface{}, 0, len(nodes))
+		for _, n := range nodes {
+			row := make(map[string]interface{}, len(columns))
+			for _, c := range columns {
+				row[c] = value(n, c)
+			}
+			rows = append(rows, row)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(rows)
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write(columns); err != nil {
+			return err
+		}
+		for _, n := range nodes {
+			record := make([]string, len(columns))
+			for i, c := range columns {
+				record[i] = formatValue(value(n, c))
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// columns returns the columns of the table with the ID column first.
+func columns(t *dent.Table) []string {
+	columns := []string{dent.FieldID}
+	for _, c := range t.GetColumns() {
+		if c != dent.FieldID {
+			columns = append(columns, c)
+		}
+	}
+	return columns
+}
+
+// value returns the value of the given column of the node.
+func value(n *dent.Dynamic, column string) interface{} {
+	if column == dent.FieldID {
+		return n.ID
+	}
+	return n.Row[column]
+}
+
+// formatValue returns the textual representation of a column value.
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"entgo.io/ent/dialect/sql"
+	"github.com/go-kenka/dent"
+)
+
+// listFlag is a flag that can be provided multiple times.
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ", ") }
+
+func (l *listFlag) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// operators holds the supported filter operators.
+var operators = []string{"!=", ">=", "<=", "=", ">", "<", "~"}
+
+// parseWhere parses filter expressions in the form of "column<op>value".
+// An empty value with the "=" or "!=" operators matches NULL values.
+func parseWhere(t *dent.Table, exprs []string) ([]dent.Predicate, error) {
+	ps := make([]dent.Predicate, 0, len(exprs))
+	for _, expr := range exprs {
+		p, err := parseExpr(t, expr)
+		if err != nil {
+			return nil, err
+		}
+		ps = append(ps, p)
+	}
+	return ps, nil
+}
+
+// splitExpr splits the expression on its leftmost operator. The longest
+// operator is preferred at the same position, so that ">=" is not parsed
+// as ">" followed by "=".
+func splitExpr(expr string) (column, op, value string, ok bool) {
+	at := -1
+	for _, o := range operators {
+		i := strings.Index(expr, o)
+		if i <= 0 {
+			continue
+		}
+		if at == -1 || i < at || i == at && len(o) > len(op) {
+			at, op = i, o
+		}
+	}
+	if at == -1 {
+		return "", "", "", false
+	}
+	return strings.TrimSpace(expr[:at]), op, strings.TrimSpace(expr[at+len(op):]), true
+}
+
+func parseExpr(t *dent.Table, expr string) (dent.Predicate, error) {
+	column, op, s, ok := splitExpr(expr)
+	if !ok {
+		return nil, fmt.Errorf("invalid filter %q", expr)
+	}
+	if op == "~" {
+		if !t.HasColumn(column) {
+			return nil, fmt.Errorf("unknown column %q in filter %q", column, expr)
+		}
+		return func(sel *sql.Selector) { sel.Where(sql.Contains(sel.C(column), s)) }, nil
+	}
+	v, err := t.ParseValue(column, s)
+	if err != nil {
+		return nil, err
+	}
+	return func(sel *sql.Selector) {
+		c := sel.C(column)
+		switch {
+		case v == nil && op == "=":
+			sel.Where(sql.IsNull(c))
+		case v == nil && op == "!=":
+			sel.Where(sql.NotNull(c))
+		case op == "=":
+			sel.Where(sql.EQ(c, v))
+		case op == "!=":
+			sel.Where(sql.NEQ(c, v))
+		case op == ">":
+			sel.Where(sql.GT(c, v))
+		case op == ">=":
+			sel.Where(sql.GTE(c, v))
+		case op == "<":
+			sel.Where(sql.LT(c, v))
+		case op == "<=":
+			sel.Where(sql.LTE(c, v))
+		}
+	}, nil
+}
+
+// parseOrder parses order expressions in the form of "column" or "-column" (descending).
+func parseOrder(t *dent.Table, exprs []string) ([]dent.OrderFunc, error) {
+	order := make([]dent.OrderFunc, 0, len(exprs))
+	for _, expr := range exprs {
+		column, desc := strings.TrimPrefix(expr, "-"), strings.HasPrefix(expr, "-")
+		if !t.HasColumn(column) {
+			return nil, fmt.Errorf("unknown column %q in order", column)
+		}
+		if desc {
+			order = append(order, dent.Desc(column))
+		} else {
+			order = append(order, dent.Asc(column))
+		}
+	}
+	return order, nil
+}
+
+// parseAssignments parses "column=value" arguments into column values.
+func parseAssignments(t *dent.Table, args []string) (map[string]interface{}, error) {
+	values := make(map[string]interface{}, len(args))
+	for _, arg := range args {
+		i := strings.Index(arg, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid assignment %q, expected column=value", arg)
+		}
+		v, err := t.ParseValue(arg[:i], arg[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		values[arg[:i]] = v
+	}
+	return values, nil
+}
+
+// runQuery queries the rows of a table and prints them.
+func runQuery(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	var (
+		where, order listFlag
+		name         = fs.String("table", "", "table to query")
+		fields       = fs.String("select", "", "comma-separated columns to select")
+		limit        = fs.Int("limit", 0, "maximum number of rows")
+		offset       = fs.Int("offset", 0, "number of rows to skip")
+		format       = fs.String("format", "table", "output format: table, json or csv")
+	)
+	fs.Var(&where, "where", "filter in the form of column<op>value, where op is one of = != > >= < <= ~")
+	fs.Var(&order, "order", "order column, prefixed with - for descending order")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	t, err := e.table(*name)
+	if err != nil {
+		return err
+	}
+	query, err := buildQuery(t, where, order)
+	if err != nil {
+		return err
+	}
+	if *limit > 0 {
+		query.Limit(*limit)
+	}
+	if *offset > 0 {
+		query.Offset(*offset)
+	}
+	selected := columns(t)
+	if *fields != "" {
+		selected = append([]string{dent.FieldID}, strings.Split(*fields, ",")...)
+		for _, f := range selected[1:] {
+			if !t.HasColumn(f) {
+				return fmt.Errorf("unknown column %q in select", f)
+			}
+		}
+		query.Select(selected[1:]...)
+	}
+	nodes, err := query.All(ctx)
+	if err != nil {
+		return err
+	}
+	return writeRows(os.Stdout, *format, selected, nodes)
+}
+
+// buildQuery returns a query for the given filter and order expressions.
+func buildQuery(t *dent.Table, where, order []string) (*dent.DQuery, error) {
+	ps, err := parseWhere(t, where)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := parseOrder(t, order)
+	if err != nil {
+		return nil, err
+	}
+	return t.Query().Where(ps...).Order(orders...), nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSplitExpr(t *testing.T) {
+	tests := []struct {
+		expr, column, op, value string
+	}{
+		{expr: "age>=18", column: "age", op: ">=", value: "18"},
+		{expr: "age >= 18", column: "age", op: ">=", value: "18"},
+		{expr: "name!=", column: "name", op: "!=", value: ""},
+		{expr: "msg~a=b", column: "msg", op: "~", value: "a=b"},
+		{expr: "msg=a~b", column: "msg", op: "=", value: "a~b"},
+		{expr: "msg=a>=b", column: "msg", op: "=", value: "a>=b"},
+	}
+	for _, tt := range tests {
+		column, op, value, ok := splitExpr(tt.expr)
+		if !ok || column != tt.column || op != tt.op || value != tt.value {
+			t.Errorf("splitExpr(%q) = %q, %q, %q, %v", tt.expr, column, op, value, ok)
+		}
+	}
+	for _, expr := range []string{"age", "=18", ""} {
+		if _, _, _, ok := splitExpr(expr); ok {
+			t.Errorf("splitExpr(%q) should fail", expr)
+		}
+	}
+}
+
+func TestRunQuery(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	file := filepath.Join(dir, "tables.json")
+	defs := `[{"name": "user", "columns": [{"name": "username", "type": "string"}, {"name": "msg", "type": "string", "nullable": true}]}]`
+	if err := os.WriteFile(file, []byte(defs), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	flags := []string{"-driver", "sqlite3", "-dsn", "file:" + filepath.Join(dir, "test.db") + "?_fk=1", "-schema", file}
+	for _, args := range [][]string{
+		{"migrate"},
+		{"create", "-table", "user", "username=a", "msg=x=y"},
+		{"query", "-table", "user", "-where", "msg~x=y", "-select", "username"},
+	} {
+		if err := run(ctx, append(flags, args...)); err != nil {
+			t.Fatalf("dent %v: %v", args, err)
+		}
+	}
+	err := run(ctx, append(flags, "query", "-table", "user", "-select", "username,nope"))
+	if err == nil || !strings.Contains(err.Error(), `unknown column "nope" in select`) {
+		t.Fatalf("unexpected error for unknown select column: %v", err)
+	}
+	err = run(ctx, append(flags, "query", "-table", "user", "-where", "nope~a"))
+	if err == nil || !strings.Contains(err.Error(), `unknown column "nope"`) {
+		t.Fatalf("unexpected error for unknown filter column: %v", err)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"entgo.io/ent/schema/field"
+	"github.com/go-kenka/dent"
+)
+
+// runImport inserts the rows of a CSV or JSON file into a table.
+func runImport(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	var (
+		name   = fs.String("table", "", "table to import into")
+		format = fs.String("format", "csv", "input format: csv or json")
+		batch  = fs.Int("batch", 500, "number of rows inserted per statement")
+		strict = fs.Bool("strict", false, "abort and roll back the import on the first invalid row or failed insert")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("import expects exactly one file argument")
+	}
+	t, err := e.table(*name)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	switch *format {
+	case "csv":
+		report, err := t.ImportCSV(ctx, f, dent.CSVOptions{BatchSize: *batch, Strict: *strict})
+		if err != nil {
+			return err
+		}
+		for _, rerr := range report.Errors {
+			fmt.Fprintln(os.Stderr, "skipped", rerr)
+		}
+		fmt.Printf("%d rows imported, %d skipped\n", report.Inserted, len(report.Errors))
+		return nil
+	case "json":
+		rows, err := readJSON(t, f)
+		if err != nil {
+			return err
+		}
+		if err := insertRows(ctx, t, rows, *batch); err != nil {
+			return err
+		}
+		fmt.Printf("%d rows imported\n", len(rows))
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+}
+
+// insertRows inserts the given rows into the table in batches.
+func insertRows(ctx context.Context, t *dent.Table, rows []map[string]interface{}, batch int) error {
+	if batch <= 0 {
+		batch = len(rows)
+	}
+	for i := 0; i < len(rows); i += batch {
+		end := i + batch
+		if end > len(rows) {
+			end = len(rows)
+		}
+		builders := make([]*dent.DCreate, 0, end-i)
+		for _, row := range rows[i:end] {
+			create := t.Create()
+			for column, v := range row {
+				if column == dent.FieldID {
+					create.Mutation().SetID(v.(int))
+					continue
+				}
+				create.SetValue(column, v)
+			}
+			builders = append(builders, create)
+		}
+		if _, err := t.CreateBulk(builders...).Save(ctx); err != nil {
+			return fmt.Errorf("importing rows %d-%d: %w", i+1, end, err)
+		}
+	}
+	return nil
+}
+
+// readJSON reads the rows of a JSON file holding an array of objects.
+func readJSON(t *dent.Table, r io.Reader) ([]map[string]interface{}, error) {
+	var objects []map[string]interface{}
+	if err := json.NewDecoder(r).Decode(&objects); err != nil {
+		return nil, err
+	}
+	rows := make([]map[string]interface{}, 0, len(objects))
+	for i, obj := range objects {
+		row := make(map[string]interface{}, len(obj))
+		for column, v := range obj {
+			col, ok := t.Column(column)
+			if !ok {
+				return nil, fmt.Errorf("object %d: unknown column %q", i+1, column)
+			}
+			switch v := v.(type) {
+			case string:
+				pv, err := t.ParseValue(column, v)
+				if err != nil {
+					return nil, fmt.Errorf("object %d: %w", i+1, err)
+				}
+				row[column] = pv
+			case float64:
+				// JSON numbers are decoded as float64.
+				if col.Type.Integer() {
+					if v != math.Trunc(v) {
+						return nil, fmt.Errorf("object %d: non-integer value %v for column %q", i+1, v, column)
+					}
+					row[column] = int64(v)
+				} else {
+					row[column] = v
+				}
+			case map[string]interface{}, []interface{}:
+				if col.Type != field.TypeJSON {
+					return nil, fmt.Errorf("object %d: unexpected JSON value for column %q", i+1, column)
+				}
+				b, err := json.Marshal(v)
+				if err != nil {
+					return nil, err
+				}
+				row[column] = b
+			default:
+				row[column] = v
+			}
+			if column == dent.FieldID {
+				id, ok := row[column].(int64)
+				if !ok {
+					return nil, fmt.Errorf("object %d: invalid id %v", i+1, v)
+				}
+				row[column] = int(id)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// runExport writes the rows of a table to a CSV or JSON file.
+func runExport(ctx context.Context, e *env, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	var (
+		where, order listFlag
+		name         = fs.String("table", "", "table to export")
+		format       = fs.String("format", "csv", "output format: csv or json")
+		out          = fs.String("o", "", "output file (defaults to stdout)")
+	)
+	fs.Var(&where, "where", "filter in the form of column<op>value")
+	fs.Var(&order, "order", "order column, prefixed with - for descending order")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	t, err := e.table(*name)
+	if err != nil {
+		return err
+	}
+	query, err := buildQuery(t, where, order)
+	if err != nil {
+		return err
+	}
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	if *format == "csv" {
+		return query.ExportCSV(ctx, w)
+	}
+	nodes, err := query.All(ctx)
+	if err != nil {
+		return err
+	}
+	return writeRows(w, *format, columns(t), nodes)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunImportJSON(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		file := filepath.Join(dir, name)
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return file
+	}
+	defs := write("tables.json", `[{"name": "user", "columns": [{"name": "username", "type": "string"}, {"name": "age", "type": "int"}]}]`)
+	flags := []string{"-driver", "sqlite3", "-dsn", "file:" + filepath.Join(dir, "test.db") + "?_fk=1", "-schema", defs}
+	if err := run(ctx, append(flags, "migrate")); err != nil {
+		t.Fatalf("dent migrate: %v", err)
+	}
+	if err := run(ctx, append(flags, "import", "-table", "user", "-format", "json", write("ok.json", `[{"username": "a", "age": 30}]`))); err != nil {
+		t.Fatalf("dent import: %v", err)
+	}
+	// Numbers are not truncated to the integer columns.
+	err := run(ctx, append(flags, "import", "-table", "user", "-format", "json", write("float.json", `[{"username": "b", "age": 1.5}]`)))
+	if err == nil || !strings.Contains(err.Error(), `non-integer value 1.5 for column "age"`) {
+		t.Fatalf("unexpected error for non-integer value: %v", err)
+	}
+}
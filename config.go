@@ -15,6 +15,8 @@ type config struct {
 	debug bool
 	// log used for logging on debug mode.
 	log func(...interface{})
+	// argLimit is the maximum number of arguments in one statement.
+	argLimit int
 }
 
 // Options applies the options on the config object.
@@ -47,3 +49,29 @@ func Driver(driver dialect.Driver) Option {
 		c.driver = driver
 	}
 }
+
+// MaxArgs sets the maximum number of arguments in one statement, that is used for
+// splitting bulk creates and updates into multiple statements. It defaults to 999 on
+// SQLite, the default limit before SQLite 3.32.0, and to 65535 on MySQL and PostgreSQL.
+// Newer SQLite versions support 32766 arguments by default.
+func MaxArgs(n int) Option {
+	return func(c *config) {
+		c.argLimit = n
+	}
+}
+
+// maxArgs returns the maximum number of arguments in one statement of the database,
+// that is used for splitting bulk operations into multiple statements.
+func (c config) maxArgs() int {
+	if c.argLimit > 0 {
+		return c.argLimit
+	}
+	switch c.driver.Dialect() {
+	case dialect.SQLite:
+		// SQLITE_MAX_VARIABLE_NUMBER defaults to 999 before SQLite 3.32.0.
+		return 999
+	default:
+		// MySQL and PostgreSQL use a 16-bit number of parameters.
+		return 65535
+	}
+}
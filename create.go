@@ -2,9 +2,14 @@ package dent
 
 import (
 	"context"
+	stdsql "database/sql"
+	"database/sql/driver"
 	"fmt"
+	"io"
 
 	"entgo.io/ent"
+	"entgo.io/ent/dialect"
+	"entgo.io/ent/dialect/sql"
 	"entgo.io/ent/dialect/sql/sqlgraph"
 	"entgo.io/ent/schema/field"
 )
@@ -12,6 +17,7 @@ import (
 // DCreate is the builder for creating a Dynamic entity.
 type DCreate struct {
 	mutation *DMutation
+	conflict []sql.ConflictOption
 }
 
 // SetCreatorID sets the "creator_id" field.
@@ -102,6 +108,7 @@ func (dc *DCreate) createSpec() (*Dynamic, *sqlgraph.CreateSpec) {
 			},
 		}
 	)
+	_spec.OnConflict = dc.conflict
 	if id, ok := dc.mutation.ID(); ok {
 		_node.ID = id
 		_spec.ID.Value = id
@@ -120,20 +127,122 @@ func (dc *DCreate) createSpec() (*Dynamic, *sqlgraph.CreateSpec) {
 	return _node, _spec
 }
 
+// unset reports if v is a placeholder value that was added by withField,
+// for a field that was not set on the mutation.
+func unset(v ent.Value) bool {
+	switch v := v.(type) {
+	case *[]byte:
+		return *v == nil
+	case driver.Valuer:
+		dv, err := v.Value()
+		return err == nil && dv == nil
+	default:
+		return false
+	}
+}
+
 // DCreateBulk is the builder for creating many Dynamic entities in bulk.
 type DCreateBulk struct {
 	config
-	builders []*DCreate
+	builders  []*DCreate
+	conflict  []sql.ConflictOption
+	nonAtomic bool
+}
+
+// NonAtomic commits each insert statement of the bulk on its own, if the bulk is too
+// large for a single statement and is split into chunks. By default, all chunks are
+// inserted in one transaction. On failure, the chunks that were inserted before the
+// failed one are kept.
+func (dcb *DCreateBulk) NonAtomic() *DCreateBulk {
+	dcb.nonAtomic = true
+	return dcb
 }
 
-// Save creates the Dynamic entities in the database.
+// Save creates the Dynamic entities in the database. The entities are inserted in chunks
+// that respect the maximum number of arguments of a statement of the database (see the
+// MaxArgs option), all in one transaction unless NonAtomic is set.
 func (dcb *DCreateBulk) Save(ctx context.Context) ([]*Dynamic, error) {
-	specs := make([]*sqlgraph.CreateSpec, len(dcb.builders))
-	nodes := make([]*Dynamic, len(dcb.builders))
-	mutators := make([]Mutator, len(dcb.builders))
-	for i := range dcb.builders {
+	chunks := dcb.chunks()
+	if len(chunks) <= 1 {
+		return dcb.save(ctx, dcb.driver, dcb.builders)
+	}
+	drv := dcb.driver
+	if _, ok := drv.(*txDriver); !ok && !dcb.nonAtomic {
+		tx, err := newTx(ctx, drv)
+		if err != nil {
+			return nil, fmt.Errorf("ent: starting a transaction: %w", err)
+		}
+		nodes, err := dcb.saveChunks(ctx, tx, chunks)
+		if err != nil {
+			if rerr := tx.tx.Rollback(); rerr != nil {
+				err = fmt.Errorf("%w: rolling back transaction: %v", err, rerr)
+			}
+			return nil, err
+		}
+		return nodes, tx.tx.Commit()
+	}
+	return dcb.saveChunks(ctx, drv, chunks)
+}
+
+// saveChunks creates the chunks of builders one after the other.
+func (dcb *DCreateBulk) saveChunks(ctx context.Context, drv dialect.Driver, chunks [][]*DCreate) ([]*Dynamic, error) {
+	nodes := make([]*Dynamic, 0, len(dcb.builders))
+	for _, chunk := range chunks {
+		created, err := dcb.save(ctx, drv, chunk)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, created...)
+	}
+	return nodes, nil
+}
+
+// chunks splits the builders into chunks that fit the argument limit of the dialect.
+func (dcb *DCreateBulk) chunks() [][]*DCreate {
+	var (
+		chunks  [][]*DCreate
+		start   int
+		columns = make(map[string]struct{})
+		limit   = dcb.maxArgs()
+	)
+	for i, b := range dcb.builders {
+		// The values of a batch insert are written for the union
+		// of the columns of all rows in the statement.
+		added := make([]string, 0, len(b.mutation.data)+1)
+		if _, ok := b.mutation.ID(); ok {
+			added = append(added, FieldID)
+		}
+		for k := range b.mutation.data {
+			added = append(added, k)
+		}
+		union := len(columns)
+		for _, c := range added {
+			if _, ok := columns[c]; !ok {
+				union++
+			}
+		}
+		if i > start && (i-start+1)*union > limit {
+			chunks = append(chunks, dcb.builders[start:i])
+			start, columns = i, make(map[string]struct{})
+		}
+		for _, c := range added {
+			columns[c] = struct{}{}
+		}
+	}
+	if start < len(dcb.builders) {
+		chunks = append(chunks, dcb.builders[start:])
+	}
+	return chunks
+}
+
+// save creates the given builders in one statement.
+func (dcb *DCreateBulk) save(ctx context.Context, drv dialect.Driver, builders []*DCreate) ([]*Dynamic, error) {
+	specs := make([]*sqlgraph.CreateSpec, len(builders))
+	nodes := make([]*Dynamic, len(builders))
+	mutators := make([]Mutator, len(builders))
+	for i := range builders {
 		func(i int, root context.Context) {
-			builder := dcb.builders[i]
+			builder := builders[i]
 			builder.defaults()
 			var mut Mutator = MutateFunc(func(ctx context.Context, m Mutation) (Value, error) {
 				mutation, ok := m.(*DMutation)
@@ -147,11 +256,11 @@ func (dcb *DCreateBulk) Save(ctx context.Context) ([]*Dynamic, error) {
 				nodes[i], specs[i] = builder.createSpec()
 				var err error
 				if i < len(mutators)-1 {
-					_, err = mutators[i+1].Mutate(root, dcb.builders[i+1].mutation)
+					_, err = mutators[i+1].Mutate(root, builders[i+1].mutation)
 				} else {
-					spec := &sqlgraph.BatchCreateSpec{Nodes: specs}
+					spec := &sqlgraph.BatchCreateSpec{Nodes: specs, OnConflict: dcb.conflict}
 					// Invoke the actual operation on the latest mutation in the chain.
-					if err = sqlgraph.BatchCreate(ctx, dcb.driver, spec); err != nil {
+					if err = sqlgraph.BatchCreate(ctx, batchDriver{drv}, spec); err != nil {
 						if sqlgraph.IsConstraintError(err) {
 							err = &ConstraintError{msg: err.Error(), wrap: err}
 						}
@@ -172,13 +281,85 @@ func (dcb *DCreateBulk) Save(ctx context.Context) ([]*Dynamic, error) {
 		}(i, ctx)
 	}
 	if len(mutators) > 0 {
-		if _, err := mutators[0].Mutate(ctx, dcb.builders[0].mutation); err != nil {
+		if _, err := mutators[0].Mutate(ctx, builders[0].mutation); err != nil {
 			return nil, err
 		}
 	}
 	return nodes, nil
 }
 
+// batchDriver is the driver of batch inserts. The IDs that are returned by the insert
+// statement are read when it is executed, and their error is returned by Query, as
+// the batch insert of sqlgraph ignores the errors of the rows (e.g. the constraint
+// errors of SQLite, that are reported when the returned rows are read).
+type batchDriver struct {
+	dialect.Driver
+}
+
+// Query executes the query and reads the returned IDs.
+func (d batchDriver) Query(ctx context.Context, query string, args, v interface{}) error {
+	rows, ok := v.(*sql.Rows)
+	if !ok {
+		return d.Driver.Query(ctx, query, args, v)
+	}
+	if err := d.Driver.Query(ctx, query, args, rows); err != nil {
+		return err
+	}
+	defer rows.Close()
+	ids := &idRows{i: -1}
+	for rows.Next() {
+		var id interface{}
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		ids.values = append(ids.values, id)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if err := rows.Close(); err != nil {
+		return err
+	}
+	rows.ColumnScanner = ids
+	return nil
+}
+
+// idRows holds the IDs that were read by batchDriver.
+type idRows struct {
+	values []interface{}
+	i      int
+}
+
+func (r *idRows) Close() error                               { return nil }
+func (r *idRows) ColumnTypes() ([]*stdsql.ColumnType, error) { return nil, nil }
+func (r *idRows) Columns() ([]string, error)                 { return []string{FieldID}, nil }
+func (r *idRows) Err() error                                 { return nil }
+func (r *idRows) NextResultSet() bool                        { return false }
+
+func (r *idRows) Next() bool {
+	r.i++
+	return r.i < len(r.values)
+}
+
+func (r *idRows) Scan(dest ...interface{}) error {
+	if len(dest) != 1 {
+		return fmt.Errorf("ent: unexpected number of scanned id columns: %d", len(dest))
+	}
+	switch d := dest[0].(type) {
+	case *interface{}:
+		*d = r.values[r.i]
+	case *int64:
+		id, ok := r.values[r.i].(int64)
+		if !ok {
+			return fmt.Errorf("ent: unexpected id type %T", r.values[r.i])
+		}
+		*d = id
+	default:
+		return fmt.Errorf("ent: unexpected id scan type %T", d)
+	}
+	return nil
+}
+
 // SaveX is like Save, but panics if an error occurs.
 func (dcb *DCreateBulk) SaveX(ctx context.Context) []*Dynamic {
 	v, err := dcb.Save(ctx)
@@ -200,3 +381,69 @@ func (dcb *DCreateBulk) ExecX(ctx context.Context) {
 		panic(err)
 	}
 }
+
+// CreateIter returns the builders of the rows inserted by InsertMany,
+// one at a time. It returns io.EOF when there are no more rows.
+type CreateIter func() (*DCreate, error)
+
+// InsertOptions configures InsertMany.
+type InsertOptions struct {
+	// BatchSize is the number of rows that are read from the iterator before
+	// they are inserted. Defaults to 1000. Each batch may be split further to
+	// respect the argument limit of the database.
+	BatchSize int
+	// Atomic inserts all rows in one transaction. By default, every
+	// batch is committed on its own. If the table already runs in a
+	// transaction, the rows are inserted in it.
+	Atomic bool
+}
+
+// InsertMany reads the rows from the iterator and inserts them in batches,
+// without holding all rows in memory. It returns the number of inserted rows,
+// which are the rows that were inserted before the error, if any. If the table
+// runs in a transaction, these rows are left to its owner to commit or roll back.
+//
+//	i := 0
+//	n, err := client.Table("user").InsertMany(ctx, func() (*DCreate, error) {
+//		if i++; i > 1_000_000 {
+//			return nil, io.EOF
+//		}
+//		return client.Table("user").Create().SetValue("age", i), nil
+//	}, InsertOptions{})
+func (c *Table) InsertMany(ctx context.Context, next CreateIter, opts InsertOptions) (int, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1000
+	}
+	var inserted int
+	insert := func(t *Table) error {
+		builders := make([]*DCreate, 0, opts.BatchSize)
+		for {
+			b, err := next()
+			if err != nil && err != io.EOF {
+				return err
+			}
+			if b != nil {
+				builders = append(builders, b)
+			}
+			if len(builders) > 0 && (len(builders) == opts.BatchSize || err == io.EOF) {
+				if _, err := t.CreateBulk(builders...).Save(ctx); err != nil {
+					return err
+				}
+				inserted += len(builders)
+				builders = builders[:0]
+			}
+			if err == io.EOF {
+				return nil
+			}
+		}
+	}
+	_, intx := c.driver.(*txDriver)
+	if !opts.Atomic || intx {
+		return inserted, insert(c)
+	}
+	if err := c.withTx(ctx, insert); err != nil {
+		// All rows were rolled back.
+		return 0, err
+	}
+	return inserted, nil
+}
@@ -0,0 +1,102 @@
+package dent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+)
+
+func TestCreateBulkChunks(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("tags")
+	table.AddColumn(&schema.Column{Name: "name", Type: field.TypeString, Unique: true})
+	client := openTest(t, table)
+	// Each row takes one argument, and bulks are split into chunks of 2 rows.
+	client.argLimit = 2
+	tags := client.Table("tags")
+	bulk := func(names ...string) *DCreateBulk {
+		builders := make([]*DCreate, len(names))
+		for i, name := range names {
+			builders[i] = tags.Create().SetValue("name", name)
+		}
+		return tags.CreateBulk(builders...)
+	}
+	nodes, err := bulk("a", "b", "c", "d", "e").Save(ctx)
+	if err != nil {
+		t.Fatalf("failed creating bulk: %v", err)
+	}
+	for i, n := range tags.Query().Order(Asc(FieldID)).AllX(ctx) {
+		if nodes[i].ID != n.ID || n.Row["name"] != string(rune('a'+i)) {
+			t.Errorf("unexpected node %d: %d %v", i, nodes[i].ID, n.Row)
+		}
+	}
+	// Chunks are inserted in one transaction by default.
+	if _, err := bulk("f", "g", "a").Save(ctx); !IsConstraintError(err) {
+		t.Fatalf("expected constraint error, got: %v", err)
+	}
+	if n := tags.Query().CountX(ctx); n != 5 {
+		t.Errorf("expected the bulk to be rolled back, got %d rows", n)
+	}
+	if _, err := bulk("f", "g", "a").NonAtomic().Save(ctx); !IsConstraintError(err) {
+		t.Fatalf("expected constraint error, got: %v", err)
+	}
+	if n := tags.Query().CountX(ctx); n != 7 {
+		t.Errorf("expected the first chunk to be kept, got %d rows", n)
+	}
+}
+
+func TestInsertMany(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("events")
+	table.AddColumn(&schema.Column{Name: "name", Type: field.TypeString})
+	table.AddColumn(&schema.Column{Name: "seq", Type: field.TypeInt, Unique: true})
+	client := openTest(t, table)
+	events := client.Table("events")
+	iter := func(seqs ...int) CreateIter {
+		return func() (*DCreate, error) {
+			if len(seqs) == 0 {
+				return nil, io.EOF
+			}
+			seq := seqs[0]
+			seqs = seqs[1:]
+			return events.Create().SetValue("name", fmt.Sprint("e", seq)).SetValue("seq", seq), nil
+		}
+	}
+	seqs := make([]int, 1200)
+	for i := range seqs {
+		seqs[i] = i
+	}
+	n, err := events.InsertMany(ctx, iter(seqs...), InsertOptions{BatchSize: 700})
+	if err != nil || n != len(seqs) {
+		t.Fatalf("failed inserting rows: %d, %v", n, err)
+	}
+	// Batches before the failed one are committed, unless the insert is atomic.
+	n, err = events.InsertMany(ctx, iter(2000, 2001, 1), InsertOptions{BatchSize: 2})
+	if !IsConstraintError(err) || n != 2 {
+		t.Errorf("unexpected result of failed insert: %d, %v", n, err)
+	}
+	n, err = events.InsertMany(ctx, iter(3000, 3001, 1), InsertOptions{BatchSize: 2, Atomic: true})
+	if !IsConstraintError(err) || n != 0 {
+		t.Errorf("unexpected result of failed atomic insert: %d, %v", n, err)
+	}
+	if count := events.Query().CountX(ctx); count != len(seqs)+2 {
+		t.Errorf("unexpected number of rows: %d", count)
+	}
+	// Rows inserted in a transaction of the caller are not rolled back.
+	tx, err := client.Tx(ctx)
+	if err != nil {
+		t.Fatalf("failed starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+	n, err = tx.Client().Table("events").InsertMany(ctx, iter(4000, 4001, 1), InsertOptions{BatchSize: 2, Atomic: true})
+	if !IsConstraintError(err) || n != 2 {
+		t.Errorf("unexpected result of failed atomic insert in transaction: %d, %v", n, err)
+	}
+	if count := tx.Client().Table("events").Query().CountX(ctx); count != len(seqs)+4 {
+		t.Errorf("unexpected number of rows in transaction: %d", count)
+	}
+}
@@ -0,0 +1,260 @@
+package dent
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"entgo.io/ent/dialect/sql"
+)
+
+// CSVOptions configures the CSV import of a table.
+type CSVOptions struct {
+	// Comma is the field delimiter. Defaults to ','.
+	Comma rune
+	// Columns maps CSV headers to table columns. Headers that
+	// are not mapped are matched with the column of the same name.
+	Columns map[string]string
+	// SkipUnknown ignores headers that do not match any column,
+	// instead of failing the import.
+	SkipUnknown bool
+	// BatchSize is the number of rows inserted in one statement. Defaults to 500.
+	BatchSize int
+	// Strict runs the import in one transaction, that fails and rolls back all
+	// inserted rows on the first invalid row or failed insert (all-or-nothing).
+	// By default, invalid rows and rows that fail to insert (e.g. on constraint
+	// violations) are reported and skipped, and the other rows are inserted.
+	Strict bool
+}
+
+// ImportReport describes the result of a CSV import.
+type ImportReport struct {
+	// Rows is the number of data rows that were read.
+	Rows int
+	// Inserted is the number of rows that were inserted.
+	Inserted int
+	// Errors holds the rows that were skipped.
+	Errors []*RowError
+}
+
+// RowError describes an invalid row in an imported file.
+type RowError struct {
+	Line   int    // Line number in the file.
+	Column string // Column name, if the error is related to a column.
+	err    error
+}
+
+// Error implements the error interface.
+func (e *RowError) Error() string {
+	if e.Column != "" {
+		return fmt.Sprintf("line %d, column %q: %v", e.Line, e.Column, e.err)
+	}
+	return fmt.Sprintf("line %d: %v", e.Line, e.err)
+}
+
+// Unwrap implements the errors.Wrapper interface.
+func (e *RowError) Unwrap() error {
+	return e.err
+}
+
+// ImportCSV reads the rows of a CSV file from r and inserts them into the table.
+// The first record holds the headers, which are mapped to the table columns, and
+// the values are converted to the column types using ParseValue. Rows are inserted
+// in batches, and the errors of rows are reported with their line numbers (see
+// CSVOptions.Strict).
+func (c *Table) ImportCSV(ctx context.Context, r io.Reader, opts CSVOptions) (*ImportReport, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 500
+	}
+	cr := csv.NewReader(r)
+	if opts.Comma != 0 {
+		cr.Comma = opts.Comma
+	}
+	cr.ReuseRecord = true
+	header, err := cr.Read()
+	if err == io.EOF {
+		return &ImportReport{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ent: reading csv header: %w", err)
+	}
+	// columns holds the table column of each CSV field, or an empty string if it is skipped.
+	columns := make([]string, len(header))
+	for i, h := range header {
+		name := h
+		if mapped, ok := opts.Columns[h]; ok {
+			name = mapped
+		}
+		switch {
+		case c.HasColumn(name):
+			columns[i] = name
+		case !opts.SkipUnknown:
+			return nil, &ValidationError{Name: h, err: fmt.Errorf("ent: unknown column %q for table %q", name, c.Name)}
+		}
+	}
+	report := &ImportReport{}
+	var (
+		builders = make([]*DCreate, 0, opts.BatchSize)
+		lines    = make([]int, 0, opts.BatchSize)
+	)
+	// flush inserts the batch of rows. In strict mode, a failed batch fails the import.
+	// Otherwise, the rows of a failed batch are inserted one by one, and the rows that
+	// fail are reported and skipped.
+	flush := func(t *Table) error {
+		if len(builders) == 0 {
+			return nil
+		}
+		defer func() {
+			builders, lines = builders[:0], lines[:0]
+		}()
+		_, err := t.CreateBulk(builders...).Save(ctx)
+		switch {
+		case err == nil:
+			report.Inserted += len(builders)
+			return nil
+		case opts.Strict:
+			return fmt.Errorf("ent: inserting csv lines %d-%d: %w", lines[0], lines[len(lines)-1], err)
+		}
+		for i, b := range builders {
+			if _, err := b.Save(ctx); err != nil {
+				report.Errors = append(report.Errors, &RowError{Line: lines[i], err: err})
+				continue
+			}
+			report.Inserted++
+		}
+		return nil
+	}
+	read := func(t *Table) error {
+		for line := 2; ; line++ {
+			record, err := cr.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+				return fmt.Errorf("ent: reading csv: %w", err)
+			}
+			report.Rows++
+			create, rerr := t.csvCreate(line, columns, record, err)
+			if rerr != nil {
+				if opts.Strict {
+					return rerr
+				}
+				report.Errors = append(report.Errors, rerr)
+				continue
+			}
+			builders, lines = append(builders, create), append(lines, line)
+			if len(builders) == opts.BatchSize {
+				if err := flush(t); err != nil {
+					return err
+				}
+			}
+		}
+		return flush(t)
+	}
+	if opts.Strict {
+		err = c.withTx(ctx, read)
+	} else {
+		err = read(c)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// csvCreate returns the create builder for the given CSV record.
+func (c *Table) csvCreate(line int, columns, record []string, err error) (*DCreate, *RowError) {
+	if err != nil || len(record) != len(columns) {
+		return nil, &RowError{Line: line, err: fmt.Errorf("expected %d fields, got %d", len(columns), len(record))}
+	}
+	create := c.Create()
+	for i, column := range columns {
+		if column == "" {
+			continue
+		}
+		v, err := c.ParseValue(column, record[i])
+		if err != nil {
+			return nil, &RowError{Line: line, Column: column, err: err}
+		}
+		if column == FieldID {
+			id, ok := v.(int64)
+			if !ok {
+				return nil, &RowError{Line: line, Column: column, err: fmt.Errorf("missing id value")}
+			}
+			create.mutation.SetID(int(id))
+			continue
+		}
+		create.SetValue(column, v)
+	}
+	return create, nil
+}
+
+// ExportCSV executes the query and writes its rows to w as CSV, including a header
+// record of the selected columns, that is written even if the query has no rows.
+// The rows are written while they are read from the database, without loading the
+// whole result into memory.
+func (dq *DQuery) ExportCSV(ctx context.Context, w io.Writer) error {
+	if err := dq.prepareQuery(ctx); err != nil {
+		return err
+	}
+	var (
+		cw     = csv.NewWriter(w)
+		record []string
+	)
+	err := dq.sqlEach(ctx, func(columns []string) error {
+		record = make([]string, len(columns))
+		return cw.Write(columns)
+	}, func(_ []string, values []interface{}) error {
+		for i := range values {
+			record[i] = csvValue(values[i])
+		}
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvValue returns the CSV representation of a scanned value. NULL values are
+// written as empty strings.
+func csvValue(v interface{}) string {
+	switch v := v.(type) {
+	case *sql.NullInt64:
+		if v.Valid {
+			return strconv.FormatInt(v.Int64, 10)
+		}
+	case *sql.NullFloat64:
+		if v.Valid {
+			return strconv.FormatFloat(v.Float64, 'f', -1, 64)
+		}
+	case *sql.NullBool:
+		if v.Valid {
+			return strconv.FormatBool(v.Bool)
+		}
+	case *sql.NullString:
+		if v.Valid {
+			return v.String
+		}
+	case *sql.NullTime:
+		if v.Valid {
+			return v.Time.Format(time.RFC3339Nano)
+		}
+	case *[]byte:
+		return string(*v)
+	case *interface{}:
+		switch v := (*v).(type) {
+		case nil:
+		case []byte:
+			return string(v)
+		default:
+			return fmt.Sprint(v)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,99 @@
+package dent
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+)
+
+func csvTable() *schema.Table {
+	table := NewTable("people")
+	table.AddColumn(&schema.Column{Name: "name", Type: field.TypeString, Unique: true})
+	table.AddColumn(&schema.Column{Name: "age", Type: field.TypeInt, Nullable: true})
+	return table
+}
+
+const peopleCSV = `name,age
+a,1
+b,x
+a,2
+c,
+`
+
+func TestImportCSV(t *testing.T) {
+	ctx := context.Background()
+	client := openTest(t, csvTable())
+	report, err := client.Table("people").ImportCSV(ctx, strings.NewReader(peopleCSV), CSVOptions{BatchSize: 2})
+	if err != nil {
+		t.Fatalf("failed importing csv: %v", err)
+	}
+	if report.Rows != 4 || report.Inserted != 2 || len(report.Errors) != 2 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if e := report.Errors[0]; e.Line != 3 || e.Column != "age" {
+		t.Errorf("unexpected error of invalid value: %v", e)
+	}
+	if e := report.Errors[1]; e.Line != 4 || e.Column != "" || !IsConstraintError(e) {
+		t.Errorf("unexpected error of duplicate row: %v", e)
+	}
+	names, err := client.Table("people").Query().Order(Asc("id")).All(ctx)
+	if err != nil {
+		t.Fatalf("failed querying rows: %v", err)
+	}
+	if len(names) != 2 || names[0].Row["name"] != "a" || names[0].Row["age"] != int64(1) || names[1].Row["name"] != "c" {
+		t.Errorf("unexpected rows: %v, %v", names[0].Row, names[1].Row)
+	}
+}
+
+func TestImportCSVStrict(t *testing.T) {
+	ctx := context.Background()
+	client := openTest(t, csvTable())
+	input := "name,age\na,1\nb,2\na,3\n"
+	_, err := client.Table("people").ImportCSV(ctx, strings.NewReader(input), CSVOptions{BatchSize: 10, Strict: true})
+	if !IsConstraintError(err) || !strings.Contains(err.Error(), "lines 2-4") {
+		t.Fatalf("expected constraint error of the failed batch, got: %v", err)
+	}
+	if n := client.Table("people").Query().CountX(ctx); n != 0 {
+		t.Errorf("expected the import to be rolled back, got %d rows", n)
+	}
+	_, err = client.Table("people").ImportCSV(ctx, strings.NewReader(peopleCSV), CSVOptions{Strict: true})
+	if e, ok := err.(*RowError); !ok || e.Line != 3 || e.Column != "age" {
+		t.Fatalf("expected error of the invalid row, got: %v", err)
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	ctx := context.Background()
+	client := openTest(t, csvTable())
+	client.Table("people").Create().SetValue("name", "a").SetValue("age", 1).SaveX(ctx)
+	client.Table("people").Create().SetValue("name", "b,c").SaveX(ctx)
+	var buf bytes.Buffer
+	if err := client.Table("people").Query().Order(Asc("id")).ExportCSV(ctx, &buf); err != nil {
+		t.Fatalf("failed exporting csv: %v", err)
+	}
+	if want := "id,name,age\n1,a,1\n2,\"b,c\",\n"; buf.String() != want {
+		t.Fatalf("unexpected csv:\n%s\nwant:\n%s", buf.String(), want)
+	}
+	// The exported file can be imported back.
+	copied := openTest(t, csvTable())
+	report, err := copied.Table("people").ImportCSV(ctx, &buf, CSVOptions{Strict: true})
+	if err != nil || report.Inserted != 2 {
+		t.Fatalf("failed importing exported csv: %v, %+v", err, report)
+	}
+	// Empty results are written with their header, and can be imported back.
+	buf.Reset()
+	if err := client.Table("people").Query().Where(StringEQ("name", "nope")).ExportCSV(ctx, &buf); err != nil {
+		t.Fatalf("failed exporting csv: %v", err)
+	}
+	if want := "id,name,age\n"; buf.String() != want {
+		t.Fatalf("unexpected csv of empty result:\n%s\nwant:\n%s", buf.String(), want)
+	}
+	report, err = copied.Table("people").ImportCSV(ctx, &buf, CSVOptions{Strict: true})
+	if err != nil || report.Inserted != 0 {
+		t.Fatalf("failed importing empty csv: %v, %+v", err, report)
+	}
+}
@@ -0,0 +1,169 @@
+package dent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+)
+
+// TableDef is the serializable definition of a dynamic table. It is used
+// for reading table definitions from files and for writing them back.
+type TableDef struct {
+	Name    string       `json:"name"`
+	Columns []*ColumnDef `json:"columns"`
+	Indexes []*IndexDef  `json:"indexes,omitempty"`
+}
+
+// ColumnDef is the serializable definition of a table column.
+type ColumnDef struct {
+	Name     string      `json:"name"`
+	Type     string      `json:"type"`
+	Size     int64       `json:"size,omitempty"`
+	Nullable bool        `json:"nullable,omitempty"`
+	Unique   bool        `json:"unique,omitempty"`
+	Default  interface{} `json:"default,omitempty"`
+	Enums    []string    `json:"enums,omitempty"`
+}
+
+// IndexDef is the serializable definition of a table index.
+type IndexDef struct {
+	Name     string   `json:"name"`
+	Unique   bool     `json:"unique,omitempty"`
+	FullText bool     `json:"full_text,omitempty"`
+	Columns  []string `json:"columns"`
+}
+
+// NewTableDef returns the definition of the given table. The ID column
+// is omitted, as it is added implicitly by NewTable.
+func NewTableDef(t *schema.Table) *TableDef {
+	def := &TableDef{Name: t.Name}
+	for _, c := range t.Columns {
+		if c.Name == FieldID {
+			continue
+		}
+		def.Columns = append(def.Columns, &ColumnDef{
+			Name:     c.Name,
+			Type:     typeNames[c.Type],
+			Size:     c.Size,
+			Nullable: c.Nullable,
+			Unique:   c.Unique,
+			Default:  c.Default,
+			Enums:    c.Enums,
+		})
+	}
+	for _, idx := range t.Indexes {
+		columns := make([]string, 0, len(idx.Columns))
+		for _, c := range idx.Columns {
+			columns = append(columns, c.Name)
+		}
+		def.Indexes = append(def.Indexes, &IndexDef{Name: idx.Name, Unique: idx.Unique, FullText: IsFullText(idx), Columns: columns})
+	}
+	return def
+}
+
+// Table builds the schema table described by the definition.
+func (d *TableDef) Table() (*schema.Table, error) {
+	if d.Name == "" {
+		return nil, fmt.Errorf("dent: missing table name in definition")
+	}
+	t := NewTable(d.Name)
+	for _, c := range d.Columns {
+		if c.Name == FieldID {
+			continue
+		}
+		typ, err := parseType(c.Type)
+		if err != nil {
+			return nil, &ValidationError{Name: c.Name, err: err}
+		}
+		col := &schema.Column{
+			Name:     c.Name,
+			Type:     typ,
+			Size:     c.Size,
+			Nullable: c.Nullable,
+			Unique:   c.Unique,
+			Default:  c.Default,
+			Enums:    c.Enums,
+		}
+		// JSON numbers are decoded as float64.
+		if f, ok := c.Default.(float64); ok && typ.Integer() {
+			col.Default = int64(f)
+		}
+		t.AddColumn(col)
+	}
+	for _, idx := range d.Indexes {
+		for _, c := range idx.Columns {
+			if !t.HasColumn(c) {
+				return nil, &ValidationError{Name: c, err: fmt.Errorf("dent: unknown column %q for index %q", c, idx.Name)}
+			}
+		}
+		if idx.FullText {
+			if idx.Unique {
+				return nil, &ValidationError{Name: idx.Name, err: fmt.Errorf("dent: full-text index %q cannot be unique", idx.Name)}
+			}
+			if err := checkFullText(t, idx.Name, idx.Columns); err != nil {
+				return nil, err
+			}
+			AddFullTextIndex(t, idx.Name, idx.Columns...)
+			continue
+		}
+		t.AddIndex(idx.Name, idx.Unique, idx.Columns)
+	}
+	return t, nil
+}
+
+// ReadTableDefs reads a JSON array of table definitions from r.
+func ReadTableDefs(r io.Reader) ([]*TableDef, error) {
+	var defs []*TableDef
+	if err := json.NewDecoder(r).Decode(&defs); err != nil {
+		return nil, fmt.Errorf("dent: decoding table definitions: %w", err)
+	}
+	return defs, nil
+}
+
+// WriteTableDefs writes the definitions of the given tables to w as a JSON array.
+func WriteTableDefs(w io.Writer, tables ...*schema.Table) error {
+	defs := make([]*TableDef, 0, len(tables))
+	for _, t := range tables {
+		defs = append(defs, NewTableDef(t))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(defs)
+}
+
+// typeNames holds the names used for column types in table definitions.
+var typeNames = map[field.Type]string{
+	field.TypeBool:    "bool",
+	field.TypeTime:    "time",
+	field.TypeJSON:    "json",
+	field.TypeUUID:    "uuid",
+	field.TypeBytes:   "bytes",
+	field.TypeEnum:    "enum",
+	field.TypeString:  "string",
+	field.TypeOther:   "other",
+	field.TypeInt8:    "int8",
+	field.TypeInt16:   "int16",
+	field.TypeInt32:   "int32",
+	field.TypeInt:     "int",
+	field.TypeInt64:   "int64",
+	field.TypeUint8:   "uint8",
+	field.TypeUint16:  "uint16",
+	field.TypeUint32:  "uint32",
+	field.TypeUint:    "uint",
+	field.TypeUint64:  "uint64",
+	field.TypeFloat32: "float32",
+	field.TypeFloat64: "float64",
+}
+
+// parseType returns the field type for its name in table definitions.
+func parseType(s string) (field.Type, error) {
+	for t, name := range typeNames {
+		if name == s {
+			return t, nil
+		}
+	}
+	return field.TypeInvalid, fmt.Errorf("dent: unknown column type %q", s)
+}
@@ -21,6 +21,9 @@ func (dd *DDelete) Where(ps ...Predicate) *DDelete {
 
 // Exec executes the deletion query and returns how many vertices were deleted.
 func (dd *DDelete) Exec(ctx context.Context) (int, error) {
+	if err := dd.mutation.check(); err != nil {
+		return 0, err
+	}
 	return dd.sqlExec(ctx)
 }
 
@@ -0,0 +1,293 @@
+package dent
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	stdsql "database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"entgo.io/ent/dialect"
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/schema/field"
+)
+
+// restoreBatchSize is the number of rows inserted in one statement on restore.
+const restoreBatchSize = 500
+
+// dumpRecord is a line in a dump. It holds either the definition
+// of a table, or one of its rows.
+type dumpRecord struct {
+	Table  string                     `json:"table"`
+	Def    *TableDef                  `json:"def,omitempty"`
+	ID     int                        `json:"id,omitempty"`
+	Values map[string]json.RawMessage `json:"values,omitempty"`
+}
+
+// Dump writes the definitions and the rows of the given tables to w as newline-delimited
+// JSON. If no tables are given, all registered tables are dumped. The output is database
+// agnostic, and can be loaded into another database using Restore.
+//
+// The tables are read in one read-only transaction with a repeatable-read isolation
+// level, to get a consistent snapshot of the rows. If the client already runs in a
+// transaction, its isolation level is used.
+//
+//	err := client.Dump(ctx, f, "user", "role")
+func (c *Client) Dump(ctx context.Context, w io.Writer, tables ...string) error {
+	if _, ok := c.driver.(*txDriver); ok {
+		return c.dump(ctx, w, tables)
+	}
+	tx, err := c.BeginTx(ctx, &sql.TxOptions{Isolation: stdsql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	if err := tx.Client().dump(ctx, w, tables); err != nil {
+		if rerr := tx.Rollback(); rerr != nil {
+			err = fmt.Errorf("%w: rolling back transaction: %v", err, rerr)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+func (c *Client) dump(ctx context.Context, w io.Writer, tables []string) error {
+	if len(tables) == 0 {
+		for _, t := range c.Tables() {
+			tables = append(tables, t.Name)
+		}
+	}
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, name := range tables {
+		t, ok := c.tmap[name]
+		if !ok {
+			return &NotFoundError{name}
+		}
+		if err := enc.Encode(&dumpRecord{Table: name, Def: NewTableDef(t)}); err != nil {
+			return err
+		}
+		query := c.Table(name).Query().Order(Asc(FieldID))
+		err := query.sqlEach(ctx, nil, func(columns []string, values []interface{}) error {
+			r := &dumpRecord{Table: name, Values: make(map[string]json.RawMessage, len(columns))}
+			for i, column := range columns {
+				v := dumpValue(values[i])
+				// JSON columns are written as JSON documents instead of base64 strings.
+				if col, ok := t.Column(column); ok && col.Type == field.TypeJSON {
+					if b, ok := v.([]byte); ok {
+						if !json.Valid(b) {
+							return fmt.Errorf("ent: invalid JSON value in column %q", column)
+						}
+						v = json.RawMessage(b)
+					}
+				}
+				if column == FieldID {
+					id, ok := v.(int64)
+					if !ok {
+						return fmt.Errorf("ent: unexpected id %v in table %q", v, name)
+					}
+					r.ID = int(id)
+					continue
+				}
+				b, err := json.Marshal(v)
+				if err != nil {
+					return fmt.Errorf("ent: encoding column %q: %w", column, err)
+				}
+				r.Values[column] = b
+			}
+			return enc.Encode(r)
+		})
+		if err != nil {
+			return fmt.Errorf("ent: dumping table %q: %w", name, err)
+		}
+	}
+	return bw.Flush()
+}
+
+// dumpValue returns the JSON value of a scanned value.
+func dumpValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case *sql.NullInt64:
+		if v.Valid {
+			return v.Int64
+		}
+	case *sql.NullFloat64:
+		if v.Valid {
+			return v.Float64
+		}
+	case *sql.NullBool:
+		if v.Valid {
+			return v.Bool
+		}
+	case *sql.NullString:
+		if v.Valid {
+			return v.String
+		}
+	case *sql.NullTime:
+		if v.Valid {
+			return v.Time
+		}
+	case *[]byte:
+		if *v != nil {
+			return *v
+		}
+	case *interface{}:
+		return *v
+	}
+	return nil
+}
+
+// Restore reads a dump written by Dump from r. Tables are created (or migrated) using
+// Schema.Create and registered on the client, and their rows are inserted in batches
+// keeping their original IDs. The dump is restored in one transaction, and nothing is
+// restored if it fails. Note that MySQL commits schema changes implicitly, and they
+// are not rolled back.
+func (c *Client) Restore(ctx context.Context, r io.Reader) error {
+	return c.withTx(ctx, func(c *Client) error {
+		return c.restore(ctx, r)
+	})
+}
+
+func (c *Client) restore(ctx context.Context, r io.Reader) error {
+	var (
+		table    *Table
+		builders []*DCreate
+		dec      = json.NewDecoder(r)
+	)
+	flush := func() error {
+		if len(builders) == 0 {
+			return nil
+		}
+		if _, err := table.CreateBulk(builders...).Save(ctx); err != nil {
+			return fmt.Errorf("ent: restoring table %q: %w", table.Name, err)
+		}
+		builders = builders[:0]
+		return nil
+	}
+	for {
+		var rec dumpRecord
+		err := dec.Decode(&rec)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("ent: decoding dump: %w", err)
+		}
+		if table != nil && table.Name != rec.Table {
+			if err := flush(); err != nil {
+				return err
+			}
+			if err := c.syncSequence(ctx, table); err != nil {
+				return err
+			}
+			table = nil
+		}
+		if rec.Def != nil {
+			t, err := rec.Def.Table()
+			if err != nil {
+				return err
+			}
+			if err := c.Schema.Create(ctx, t); err != nil {
+				return err
+			}
+			c.AddTable(t)
+			continue
+		}
+		if table == nil {
+			if _, ok := c.tmap[rec.Table]; !ok {
+				return fmt.Errorf("ent: missing definition for table %q", rec.Table)
+			}
+			table = c.Table(rec.Table)
+		}
+		create := table.Create()
+		create.mutation.SetID(rec.ID)
+		for column, raw := range rec.Values {
+			col, ok := table.Column(column)
+			if !ok {
+				return &ValidationError{Name: column, err: fmt.Errorf("ent: unknown column %q for table %q", column, rec.Table)}
+			}
+			v, err := restoreValue(col.Type, raw)
+			if err != nil {
+				return &ValidationError{Name: column, err: fmt.Errorf("ent: invalid value for column %q of table %q: %w", column, rec.Table, err)}
+			}
+			create.SetValue(column, v)
+		}
+		if builders = append(builders, create); len(builders) == restoreBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if table == nil {
+		return nil
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	return c.syncSequence(ctx, table)
+}
+
+// restoreValue converts an encoded JSON value to the Go type of a column.
+func restoreValue(typ field.Type, raw json.RawMessage) (interface{}, error) {
+	if string(raw) == "null" {
+		return nil, nil
+	}
+	// JSON columns are restored as the documents they were dumped as,
+	// as raw messages are inserted as is by the JSON encoding of columns.
+	if typ == field.TypeJSON {
+		return raw, nil
+	}
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	// Numbers are decoded as json.Number to keep the precision of large integers.
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	switch v := v.(type) {
+	case json.Number:
+		return numberValue(typ, v)
+	case string:
+		switch typ {
+		case field.TypeTime:
+			return parseTime(v)
+		case field.TypeBytes:
+			return base64.StdEncoding.DecodeString(v)
+		}
+	}
+	return v, nil
+}
+
+// numberValue converts a decoded JSON number to the Go type of a column.
+func numberValue(typ field.Type, n json.Number) (interface{}, error) {
+	switch {
+	case typ.Float():
+		return n.Float64()
+	case typ >= field.TypeUint8 && typ <= field.TypeUint64:
+		return strconv.ParseUint(n.String(), 10, 64)
+	default:
+		return n.Int64()
+	}
+}
+
+// syncSequence moves the ID sequence of the table past the restored IDs. It is
+// needed only on Postgres, as MySQL and SQLite update their counters on insert.
+func (c *Client) syncSequence(ctx context.Context, t *Table) error {
+	if c.driver.Dialect() != dialect.Postgres {
+		return nil
+	}
+	b := &sql.Builder{}
+	b.SetDialect(dialect.Postgres)
+	query := fmt.Sprintf(
+		"SELECT setval(pg_get_serial_sequence($1, $2), COALESCE(MAX(%s), 0) + 1, false) FROM %s",
+		b.Quote(FieldID), b.Quote(t.Name),
+	)
+	rows := &sql.Rows{}
+	if err := c.driver.Query(ctx, query, []interface{}{b.Quote(t.Name), FieldID}, rows); err != nil {
+		return fmt.Errorf("ent: updating id sequence of table %q: %w", t.Name, err)
+	}
+	return rows.Close()
+}
@@ -0,0 +1,95 @@
+package dent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+)
+
+func dumpTable() *schema.Table {
+	table := NewTable("docs")
+	table.AddColumn(&schema.Column{Name: "title", Type: field.TypeString})
+	table.AddColumn(&schema.Column{Name: "meta", Type: field.TypeJSON, Nullable: true})
+	table.AddColumn(&schema.Column{Name: "data", Type: field.TypeBytes, Nullable: true})
+	table.AddColumn(&schema.Column{Name: "created_at", Type: field.TypeTime})
+	return table
+}
+
+func TestDumpRestore(t *testing.T) {
+	ctx := context.Background()
+	client := openTest(t, dumpTable())
+	at := time.Date(2022, 8, 1, 10, 30, 0, 0, time.UTC)
+	client.Table("docs").Create().
+		SetValue("title", "a").
+		SetValue("meta", map[string]interface{}{"tags": []string{"x", "y"}, "n": 1}).
+		SetValue("data", []byte{0, 1, 2}).
+		SetValue("created_at", at).
+		SaveX(ctx)
+	client.Table("docs").Create().SetValue("title", "b").SetValue("created_at", at).SaveX(ctx)
+	var (
+		buf     bytes.Buffer
+		queries []string
+	)
+	client.log = func(v ...interface{}) {
+		queries = append(queries, fmt.Sprint(v...))
+	}
+	if err := client.Debug().Dump(ctx, &buf, "docs"); err != nil {
+		t.Fatalf("failed dumping tables: %v", err)
+	}
+	// The rows are read in one transaction.
+	for _, q := range queries {
+		if strings.Contains(q, "query=") && !strings.HasPrefix(q, "Tx(") {
+			t.Errorf("unexpected query outside of the dump transaction: %s", q)
+		}
+	}
+	if !strings.Contains(buf.String(), `"meta":{"n":1,"tags":["x","y"]}`) {
+		t.Errorf("expected json column to be dumped as a document:\n%s", buf.String())
+	}
+	restored := openTest(t)
+	if err := restored.Restore(ctx, &buf); err != nil {
+		t.Fatalf("failed restoring dump: %v", err)
+	}
+	want := client.Table("docs").Query().Order(Asc(FieldID)).AllX(ctx)
+	got := restored.Table("docs").Query().Order(Asc(FieldID)).AllX(ctx)
+	if len(got) != len(want) {
+		t.Fatalf("restored %d rows, want %d", len(got), len(want))
+	}
+	for i := range want {
+		for column, v := range want[i].Row {
+			if w, ok := v.(time.Time); ok {
+				if !w.Equal(got[i].Row[column].(time.Time)) {
+					t.Errorf("row %d: column %q = %v, want %v", i, column, got[i].Row[column], v)
+				}
+				continue
+			}
+			if got[i].Row[column] != v {
+				t.Errorf("row %d: column %q = %#v, want %#v", i, column, got[i].Row[column], v)
+			}
+		}
+	}
+	// New rows continue the restored ids.
+	if n := restored.Table("docs").Create().SetValue("title", "c").SetValue("created_at", at).SaveX(ctx); n.ID != 3 {
+		t.Errorf("unexpected id of new row: %d", n.ID)
+	}
+}
+
+func TestRestoreRollback(t *testing.T) {
+	ctx := context.Background()
+	client := openTest(t)
+	def := `{"table":"docs","def":{"name":"docs","columns":[{"name":"title","type":"string"}]}}`
+	dump := def + "\n" + `{"table":"docs","id":1,"values":{"title":"a"}}` + "\n" + `{"table":"docs","id":2,"values":{"nope":"b"}}` + "\n"
+	err := client.Restore(ctx, strings.NewReader(dump))
+	if !IsValidationError(err) {
+		t.Fatalf("expected validation error of unknown column, got: %v", err)
+	}
+	// The created table is rolled back with its rows.
+	if n, err := client.Table("docs").Query().Count(ctx); err == nil {
+		t.Errorf("expected the restore to be rolled back, got %d rows", n)
+	}
+}
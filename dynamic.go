@@ -14,6 +14,8 @@ type Dynamic struct {
 	// ID of the ent.
 	ID  int                  `json:"id,omitempty"`
 	Row map[string]ent.Value `json:"row,omitempty"`
+	// Aggregates holds the rollups of the related rows (see DQuery.WithCount).
+	Aggregates map[string]ent.Value `json:"aggregates,omitempty"`
 	// edges
 	Edges DynamicEdges `json:"edges"`
 }
@@ -32,6 +34,14 @@ func (d *DynamicEdges) List(key string) []*Dynamic {
 	return d.ListMap[key]
 }
 
+// newDynamic returns an empty Dynamic of the table, for scanning a row into.
+func (c *Table) newDynamic() *Dynamic {
+	node := &Dynamic{table: c, Row: make(map[string]ent.Value)}
+	node.Edges.SingleMap = make(map[string]*Dynamic)
+	node.Edges.ListMap = make(map[string][]*Dynamic)
+	return node
+}
+
 // scanValues returns the types for scanning values from sql.Rows.
 func (d *Dynamic) scanValues(columns []string) ([]interface{}, error) {
 	return d.table.scanValues(columns)
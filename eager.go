@@ -0,0 +1,252 @@
+package dent
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/sqlgraph"
+)
+
+// edgeRank is the name of the window that ranks the eager-loaded rows
+// of each parent, when their query is limited.
+const edgeRank = "edge_rank"
+
+// With configures the query builder to eager-load the rows that are related with the
+// given path of relations (see Client.AddRelation), where nested relations are separated
+// by dots. The options configure the query of the last relation of the path, and can
+// select, filter and order its rows. The limit and offset of the query of a list
+// relation apply to the rows of each parent. For example, the latest 5 comments
+// of each post:
+//
+//	client.Table("posts").Query().
+//		With("author.department").
+//		With("comments", func(q *DQuery) {
+//			q.Order(Desc("created_at")).Limit(5)
+//		}).
+//		All(ctx)
+//
+// The rows of single relations (BelongsTo and HasOne) are stored in the Edges.SingleMap
+// of their parents, and the rows of list relations in their Edges.ListMap, under the
+// name of the relation.
+func (dq *DQuery) With(path string, opts ...func(*DQuery)) *DQuery {
+	query := dq
+	for _, name := range strings.Split(path, ".") {
+		wq, ok := query.withData[name]
+		if !ok {
+			wq = query.table.withRelation(name)
+			query.withData[name] = wq
+		}
+		query = wq.query
+	}
+	for _, opt := range opts {
+		opt(query)
+	}
+	return dq
+}
+
+// withRelation returns the eager-loading configuration of the relation of the table.
+func (c *Table) withRelation(name string) *WithQuery {
+	r, err := c.relation(name)
+	if err != nil {
+		return &WithQuery{query: c.Query(), err: err}
+	}
+	return &WithQuery{relation: r, query: c.relatedTable(r).Query()}
+}
+
+// loadEdges eager-loads the related rows of the nodes.
+func (dq *DQuery) loadEdges(ctx context.Context, nodes []*Dynamic) error {
+	for key, wq := range dq.withData {
+		if wq.err != nil {
+			return wq.err
+		}
+		var err error
+		switch r := wq.relation; {
+		case r.Rel == sqlgraph.M2M:
+			err = wq.loadM2M(ctx, key, nodes)
+		case r.Rel == sqlgraph.M2O || (r.Rel == sqlgraph.O2O && r.Inverse):
+			err = wq.loadOwners(ctx, key, nodes)
+		default:
+			err = wq.loadOwned(ctx, key, nodes)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadOwners loads the rows that are referenced by the foreign-key of the nodes.
+func (wq *WithQuery) loadOwners(ctx context.Context, key string, nodes []*Dynamic) error {
+	column := wq.relation.Columns[0]
+	ids := make([]int, 0, len(nodes))
+	nodeids := make(map[int][]*Dynamic)
+	for _, n := range nodes {
+		fk, err := foreignKey(n, column, key)
+		if err != nil {
+			return err
+		}
+		if _, ok := nodeids[fk]; !ok {
+			ids = append(ids, fk)
+		}
+		nodeids[fk] = append(nodeids[fk], n)
+	}
+	neighbors, err := wq.query.Clone().Where(IDIn(ids...)).All(ctx)
+	if err != nil {
+		return err
+	}
+	for _, n := range neighbors {
+		nodes, ok := nodeids[n.ID]
+		if !ok {
+			return fmt.Errorf(`ent: unexpected id %v returned for %q`, n.ID, key)
+		}
+		for i := range nodes {
+			nodes[i].Edges.SingleMap[key] = n
+		}
+	}
+	return nil
+}
+
+// loadOwned loads the rows that reference the nodes with their foreign-key.
+func (wq *WithQuery) loadOwned(ctx context.Context, key string, nodes []*Dynamic) error {
+	column := wq.relation.Columns[0]
+	fks, nodeids := nodeIDs(nodes)
+	query := wq.query.Clone()
+	query.selectKey(column)
+	query.Where(func(s *sql.Selector) {
+		s.Where(sql.InValues(s.C(column), fks...))
+	})
+	query.limitPerParent(column)
+	neighbors, err := query.All(ctx)
+	if err != nil {
+		return err
+	}
+	for _, n := range neighbors {
+		fk, err := foreignKey(n, column, key)
+		if err != nil {
+			return err
+		}
+		nodes, ok := nodeids[fk]
+		if !ok {
+			return fmt.Errorf(`ent: unexpected foreign-key %q returned %v for node %v`, column, fk, n.ID)
+		}
+		delete(n.Row, edgeRank)
+		for _, node := range nodes {
+			if wq.relation.Rel == sqlgraph.O2O {
+				node.Edges.SingleMap[key] = n
+			} else {
+				node.Edges.ListMap[key] = append(node.Edges.ListMap[key], n)
+			}
+		}
+	}
+	return nil
+}
+
+// loadM2M loads the rows that are related to the nodes through the join table.
+func (wq *WithQuery) loadM2M(ctx context.Context, key string, nodes []*Dynamic) error {
+	r := wq.relation
+	from, to := r.Columns[0], r.Columns[1]
+	if r.Inverse {
+		from, to = to, from
+	}
+	// The rows are joined with the join table, and
+	// grouped by the prefixed column of the nodes.
+	column := r.Table + "." + from
+	fks, nodeids := nodeIDs(nodes)
+	query := wq.query.Clone().Join(r.Table, On(FieldID, to))
+	query.selectKey(column)
+	query.Where(func(s *sql.Selector) {
+		s.Where(sql.InValues(s.C(column), fks...))
+	})
+	query.limitPerParent(column)
+	neighbors, err := query.All(ctx)
+	if err != nil {
+		return err
+	}
+	for _, n := range neighbors {
+		fk, err := foreignKey(n, column, key)
+		if err != nil {
+			return err
+		}
+		nodes, ok := nodeids[fk]
+		if !ok {
+			return fmt.Errorf(`ent: unexpected foreign-key %q returned %v for node %v`, column, fk, n.ID)
+		}
+		delete(n.Row, edgeRank)
+		for c := range n.Row {
+			if strings.HasPrefix(c, r.Table+".") {
+				delete(n.Row, c)
+			}
+		}
+		for _, node := range nodes {
+			node.Edges.ListMap[key] = append(node.Edges.ListMap[key], n)
+		}
+	}
+	return nil
+}
+
+// nodeIDs returns the distinct IDs of the nodes, and the nodes by their IDs. The same
+// row may be loaded more than once as a related row of different parents.
+func nodeIDs(nodes []*Dynamic) ([]driver.Value, map[int][]*Dynamic) {
+	ids := make([]driver.Value, 0, len(nodes))
+	nodeids := make(map[int][]*Dynamic)
+	for _, n := range nodes {
+		if _, ok := nodeids[n.ID]; !ok {
+			ids = append(ids, n.ID)
+		}
+		nodeids[n.ID] = append(nodeids[n.ID], n)
+	}
+	return ids, nodeids
+}
+
+// foreignKey returns the value of the foreign-key column of the node.
+func foreignKey(n *Dynamic, column, key string) (int, error) {
+	v, ok := n.Row[column]
+	if !ok {
+		return 0, fmt.Errorf("ent: missing foreign-key %q of node %v for loading %q", column, n.ID, key)
+	}
+	fk, ok := v.(int64)
+	if !ok {
+		return 0, fmt.Errorf("ent: unexpected foreign-key %q type %T of node %v for loading %q", column, v, n.ID, key)
+	}
+	return int(fk), nil
+}
+
+// selectKey adds the key column to the selected fields of the query, if any.
+func (dq *DQuery) selectKey(column string) {
+	if len(dq.fields) == 0 {
+		return
+	}
+	for _, f := range dq.fields {
+		if f == column {
+			return
+		}
+	}
+	dq.fields = append(dq.fields, column)
+}
+
+// limitPerParent applies the limit and offset of the query on the rows of each
+// parent, by ranking the rows in the partitions of the given column.
+func (dq *DQuery) limitPerParent(column string) {
+	if dq.limit == nil && dq.offset == nil {
+		return
+	}
+	w := RowNumber().PartitionBy(column).As(edgeRank)
+	w.orders = dq.order
+	if len(dq.order) == 0 {
+		w.Asc(FieldID)
+	}
+	offset, limit := 0, dq.limit
+	if dq.offset != nil {
+		offset = *dq.offset
+	}
+	dq.limit, dq.offset = nil, nil
+	dq.Window(w).Qualify(func(s *sql.Selector) {
+		s.Where(sql.GT(s.C(edgeRank), offset))
+		if limit != nil {
+			s.Where(sql.LTE(s.C(edgeRank), offset+*limit))
+		}
+	})
+}
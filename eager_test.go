@@ -0,0 +1,90 @@
+package dent
+
+import (
+	"context"
+	"testing"
+
+	"entgo.io/ent/dialect/sql"
+)
+
+// edgeNames returns the names of the rows of the list relation of the node.
+func edgeNames(n *Dynamic, key string) []string {
+	var names []string
+	for _, e := range n.Edges.List(key) {
+		names = append(names, e.Row["name"].(string))
+	}
+	return names
+}
+
+func TestWith(t *testing.T) {
+	ctx := context.Background()
+	client, _ := openRelations(t)
+	users := client.Table("users")
+
+	// Nested relations are loaded with dotted paths.
+	nodes := users.Query().
+		With("roles", func(q *DQuery) { q.Order(Asc("name")) }).
+		With("roles.permissions", func(q *DQuery) { q.Order(Asc("name")) }).
+		Order(Asc(FieldID)).
+		AllX(ctx)
+	if len(nodes) != 3 {
+		t.Fatalf("unexpected number of users: %d", len(nodes))
+	}
+	a8m, nati, ariel := nodes[0], nodes[1], nodes[2]
+	if got := edgeNames(a8m, "roles"); !equalStrings(got, []string{"admin", "editor"}) {
+		t.Errorf("unexpected roles: %v", got)
+	}
+	if got := edgeNames(a8m.Edges.List("roles")[0], "permissions"); !equalStrings(got, []string{"delete", "write"}) {
+		t.Errorf("unexpected permissions: %v", got)
+	}
+	if got := edgeNames(nati.Edges.List("roles")[0], "permissions"); !equalStrings(got, []string{"read", "write"}) {
+		t.Errorf("unexpected permissions: %v", got)
+	}
+	if got := edgeNames(ariel, "roles"); got != nil {
+		t.Errorf("unexpected roles: %v", got)
+	}
+
+	// Single relations are stored in the SingleMap.
+	for _, n := range client.Table("permissions").Query().With("role").AllX(ctx) {
+		if role := n.Edges.Get("role"); role == nil || int64(role.ID) != n.Row["role_id"] {
+			t.Errorf("unexpected role of permission %v: %v", n.ID, role)
+		}
+	}
+
+	// Limits and offsets apply to the rows of each parent.
+	roles := client.Table("roles").Query().
+		With("permissions", func(q *DQuery) { q.Order(Desc("name")).Limit(1) }).
+		With("users", func(q *DQuery) { q.Order(Asc("name")).Offset(1) }).
+		Order(Asc("name")).
+		AllX(ctx)
+	for i, want := range []struct{ permissions, users []string }{
+		{permissions: []string{"write"}},
+		{permissions: []string{"write"}, users: []string{"nati"}},
+	} {
+		if got := edgeNames(roles[i], "permissions"); !equalStrings(got, want.permissions) {
+			t.Errorf("unexpected permissions of %v: %v", roles[i].Row["name"], got)
+		}
+		if got := edgeNames(roles[i], "users"); !equalStrings(got, want.users) {
+			t.Errorf("unexpected users of %v: %v", roles[i].Row["name"], got)
+		}
+	}
+	// Orderings by expressions can not pick the rows of each parent.
+	_, err := client.Table("roles").Query().
+		With("permissions", func(q *DQuery) {
+			q.Order(func(s *sql.Selector) { s.OrderExpr(sql.Expr("LENGTH(name)")) }).Limit(1)
+		}).
+		All(ctx)
+	if !IsValidationError(err) {
+		t.Errorf("expected validation error of expression ordering, got: %v", err)
+	}
+
+	// Clones do not share the configuration of eager-loading.
+	query := users.Query().Where(ID(a8m.ID)).With("roles")
+	query.Clone().With("roles", func(q *DQuery) { q.Limit(1) })
+	if got := len(query.OnlyX(ctx).Edges.List("roles")); got != 2 {
+		t.Errorf("unexpected number of roles: %d", got)
+	}
+	if _, err := users.Query().With("roles.groups").All(ctx); !IsValidationError(err) {
+		t.Errorf("expected validation error of unknown relation, got: %v", err)
+	}
+}
@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"entgo.io/ent"
+	"entgo.io/ent/dialect"
 	"entgo.io/ent/dialect/sql"
 	"entgo.io/ent/dialect/sql/sqlgraph"
 )
@@ -92,6 +95,59 @@ func Sum(field string) AggregateFunc {
 	}
 }
 
+// CountDistinct applies the "count distinct" aggregation function on the given field of each group.
+func CountDistinct(field string) AggregateFunc {
+	return func(s *sql.Selector) string {
+		return sql.Count(sql.Distinct(s.C(field)))
+	}
+}
+
+// Percentile applies the continuous percentile aggregation function on the given
+// field of each group, where p is between 0 and 1. It is supported by PostgreSQL only.
+func Percentile(field string, p float64) AggregateFunc {
+	return func(s *sql.Selector) string {
+		if s.Dialect() != dialect.Postgres {
+			s.AddError(fmt.Errorf("ent: percentile aggregation is not supported by %s", s.Dialect()))
+			return ""
+		}
+		if p < 0 || p > 1 {
+			s.AddError(fmt.Errorf("ent: invalid percentile %v", p))
+			return ""
+		}
+		return fmt.Sprintf("PERCENTILE_CONT(%s) WITHIN GROUP (ORDER BY %s)", strconv.FormatFloat(p, 'f', -1, 64), s.C(field))
+	}
+}
+
+// Median applies the "median" aggregation function on the given field of each group.
+// It is supported by PostgreSQL only.
+func Median(field string) AggregateFunc {
+	return Percentile(field, 0.5)
+}
+
+// StringAgg applies the string aggregation function on the given field of each group,
+// which concatenates the values of the group with the given separator. That is,
+// GROUP_CONCAT in MySQL and SQLite, and STRING_AGG in PostgreSQL.
+func StringAgg(field, sep string) AggregateFunc {
+	return func(s *sql.Selector) string {
+		column := s.C(field)
+		switch s.Dialect() {
+		case dialect.MySQL:
+			// Backslashes are escape characters in MySQL string literals.
+			escaped := strings.ReplaceAll(sep, `\`, `\\`)
+			return fmt.Sprintf("GROUP_CONCAT(%s SEPARATOR %s)", column, quoteString(escaped))
+		case dialect.Postgres:
+			return fmt.Sprintf("STRING_AGG(CAST(%s AS TEXT), %s)", column, quoteString(sep))
+		default:
+			return fmt.Sprintf("GROUP_CONCAT(%s, %s)", column, quoteString(sep))
+		}
+	}
+}
+
+// quoteString returns s as an SQL string literal.
+func quoteString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
 // ValidationError returns when validating a field or edge fails.
 type ValidationError struct {
 	Name string // Field or edge name.
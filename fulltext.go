@@ -0,0 +1,367 @@
+package dent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	atlas "ariga.io/atlas/sql/schema"
+	"entgo.io/ent/dialect"
+	"entgo.io/ent/dialect/entsql"
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+)
+
+// tsConfig is the text search configuration of full-text indexes in PostgreSQL.
+const tsConfig = "simple"
+
+// AddFullTextIndex adds a full-text index on the given string columns of the table.
+// The index is created by the schema migration as a FULLTEXT index in MySQL, a GIN
+// index on the tsvector of the columns in PostgreSQL, and an FTS5 table that is
+// kept in sync with triggers in SQLite. Note that FTS5 requires building the
+// SQLite driver with the "sqlite_fts5" tag. It panics if one of the columns is
+// not a string column of the table.
+//
+//	t := NewTable("posts")
+//	t.AddColumn(&schema.Column{Name: "title", Type: field.TypeString})
+//	t.AddColumn(&schema.Column{Name: "body", Type: field.TypeString, Size: math.MaxInt32})
+//	AddFullTextIndex(t, "posts_search", "title", "body")
+func AddFullTextIndex(t *schema.Table, name string, columns ...string) *schema.Table {
+	if err := checkFullText(t, name, columns); err != nil {
+		panic(err)
+	}
+	t.AddIndex(name, false, columns)
+	t.Indexes[len(t.Indexes)-1].Annotation = &entsql.IndexAnnotation{
+		Types: map[string]string{
+			dialect.MySQL:    "FULLTEXT",
+			dialect.Postgres: "GIN",
+			dialect.SQLite:   "FTS5",
+		},
+	}
+	return t
+}
+
+// checkFullText checks that the columns of a full-text index are string columns of the table.
+func checkFullText(t *schema.Table, name string, columns []string) error {
+	if len(columns) == 0 {
+		return &ValidationError{Name: name, err: fmt.Errorf("ent: missing columns of full-text index %q", name)}
+	}
+	for _, column := range columns {
+		c, ok := t.Column(column)
+		if !ok {
+			return &ValidationError{Name: column, err: fmt.Errorf("ent: unknown column %q for full-text index %q", column, name)}
+		}
+		if c.Type != field.TypeString {
+			return &ValidationError{Name: column, err: fmt.Errorf("ent: full-text index %q on non-string column %q", name, column)}
+		}
+	}
+	return nil
+}
+
+// IsFullText reports if the index is a full-text index.
+func IsFullText(idx *schema.Index) bool {
+	return idx.Annotation != nil && idx.Annotation.Types[dialect.MySQL] == "FULLTEXT"
+}
+
+// migrateTables returns the tables to migrate with ent. Full-text indexes
+// are created by the migration itself in MySQL, and are left out for the
+// other dialects, where they are created by createFullText.
+func migrateTables(d string, tables []*schema.Table) []*schema.Table {
+	if d == dialect.MySQL {
+		return tables
+	}
+	migrate := make([]*schema.Table, len(tables))
+	for i, t := range tables {
+		var indexes []*schema.Index
+		for _, idx := range t.Indexes {
+			if !IsFullText(idx) {
+				indexes = append(indexes, idx)
+			}
+		}
+		t2 := *t
+		t2.Indexes = indexes
+		migrate[i] = &t2
+	}
+	return migrate
+}
+
+// keepFullText returns a diff hook that keeps the full-text indexes that were left out
+// by migrateTables, and would otherwise be dropped by migrations with WithDropIndex.
+func keepFullText(tables []*schema.Table) schema.DiffHook {
+	indexes := make(map[string]map[string]bool)
+	for _, t := range tables {
+		for _, idx := range t.Indexes {
+			if !IsFullText(idx) {
+				continue
+			}
+			if indexes[t.Name] == nil {
+				indexes[t.Name] = make(map[string]bool)
+			}
+			indexes[t.Name][idx.Name] = true
+		}
+	}
+	return func(next schema.Differ) schema.Differ {
+		return schema.DiffFunc(func(current, desired *atlas.Schema) ([]atlas.Change, error) {
+			changes, err := next.Diff(current, desired)
+			if err != nil || len(indexes) == 0 {
+				return changes, err
+			}
+			kept := changes[:0]
+			for _, c := range changes {
+				m, ok := c.(*atlas.ModifyTable)
+				if !ok || indexes[m.T.Name] == nil {
+					kept = append(kept, c)
+					continue
+				}
+				modify := m.Changes[:0]
+				for _, mc := range m.Changes {
+					if d, ok := mc.(*atlas.DropIndex); ok && indexes[m.T.Name][d.I.Name] {
+						continue
+					}
+					modify = append(modify, mc)
+				}
+				if m.Changes = modify; len(modify) > 0 {
+					kept = append(kept, m)
+				}
+			}
+			return kept, nil
+		})
+	}
+}
+
+// createFullText creates the full-text indexes of the tables in PostgreSQL and SQLite.
+func createFullText(ctx context.Context, drv dialect.Driver, tables []*schema.Table) error {
+	for _, t := range tables {
+		for _, idx := range t.Indexes {
+			if !IsFullText(idx) {
+				continue
+			}
+			columns := make([]string, 0, len(idx.Columns))
+			for _, c := range idx.Columns {
+				if c.Type != field.TypeString {
+					return &ValidationError{Name: c.Name, err: fmt.Errorf("ent: full-text index %q on non-string column %q", idx.Name, c.Name)}
+				}
+				columns = append(columns, c.Name)
+			}
+			var err error
+			switch drv.Dialect() {
+			case dialect.Postgres:
+				err = createTSIndex(ctx, drv, t.Name, idx.Name, columns)
+			case dialect.SQLite:
+				err = createFTSTable(ctx, drv, t.Name, columns)
+			}
+			if err != nil {
+				return fmt.Errorf("ent/migrate: creating full-text index %q: %w", idx.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// createTSIndex creates a GIN index on the tsvector of the columns in PostgreSQL.
+func createTSIndex(ctx context.Context, drv dialect.Driver, table, name string, columns []string) error {
+	stmt := fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s USING GIN (%s)",
+		quoteIdent(dialect.Postgres, name), quoteIdent(dialect.Postgres, table), tsVector(quoteIdents(dialect.Postgres, columns)))
+	return drv.Exec(ctx, stmt, []interface{}{}, nil)
+}
+
+// createFTSTable creates the FTS5 table of the columns in SQLite, and the triggers that
+// keep it in sync with the table. Existing rows are indexed when the FTS table is created.
+func createFTSTable(ctx context.Context, drv dialect.Driver, table string, columns []string) error {
+	fts := ftsTable(table, columns)
+	rows := &sql.Rows{}
+	if err := drv.Query(ctx, "SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = ?", []interface{}{fts}, rows); err != nil {
+		return err
+	}
+	exists, err := sql.ScanBool(rows)
+	rows.Close()
+	if err != nil {
+		return err
+	}
+	var (
+		q         = func(s string) string { return quoteIdent(dialect.SQLite, s) }
+		cols      = strings.Join(quoteIdents(dialect.SQLite, columns), ", ")
+		newValues = "new." + strings.Join(quoteIdents(dialect.SQLite, columns), ", new.")
+		oldValues = "old." + strings.Join(quoteIdents(dialect.SQLite, columns), ", old.")
+		insert    = fmt.Sprintf("INSERT INTO %s(rowid, %s) VALUES (new.%s, %s);", q(fts), cols, q(FieldID), newValues)
+		remove    = fmt.Sprintf("INSERT INTO %s(%s, rowid, %s) VALUES ('delete', old.%s, %s);", q(fts), q(fts), cols, q(FieldID), oldValues)
+	)
+	stmts := []string{
+		fmt.Sprintf("CREATE VIRTUAL TABLE IF NOT EXISTS %s USING fts5(%s, content=%s, content_rowid=%s)", q(fts), cols, quoteString(table), quoteString(FieldID)),
+		fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %s AFTER INSERT ON %s BEGIN %s END", q(fts+"_ai"), q(table), insert),
+		fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %s AFTER DELETE ON %s BEGIN %s END", q(fts+"_ad"), q(table), remove),
+		fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS %s AFTER UPDATE ON %s BEGIN %s %s END", q(fts+"_au"), q(table), remove, insert),
+	}
+	if !exists {
+		stmts = append(stmts, fmt.Sprintf("INSERT INTO %s(%s) VALUES ('rebuild')", q(fts), q(fts)))
+	}
+	for _, stmt := range stmts {
+		if err := drv.Exec(ctx, stmt, []interface{}{}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ftsTable returns the name of the FTS5 table of the columns in SQLite.
+func ftsTable(table string, columns []string) string {
+	return table + "_" + strings.Join(columns, "_") + "_fts"
+}
+
+// ftsTables returns the names of the FTS5 tables in SQLite, and of their shadow tables.
+func ftsTables(ctx context.Context, drv dialect.Driver) (map[string]bool, error) {
+	rows := &sql.Rows{}
+	query := "SELECT name FROM sqlite_master WHERE type = 'table' AND sql LIKE 'CREATE VIRTUAL TABLE%' AND sql LIKE '%USING fts5%'"
+	if err := drv.Query(ctx, query, []interface{}{}, rows); err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	names := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names[name] = true
+		for _, suffix := range []string{"_data", "_idx", "_docsize", "_config", "_content"} {
+			names[name+suffix] = true
+		}
+	}
+	return names, rows.Err()
+}
+
+// tsVector returns the tsvector of the given column expressions in PostgreSQL.
+func tsVector(columns []string) string {
+	document := make([]string, len(columns))
+	for i, c := range columns {
+		document[i] = "COALESCE(" + c + ", '')"
+	}
+	return "to_tsvector(" + quoteString(tsConfig) + ", " + strings.Join(document, " || ' ' || ") + ")"
+}
+
+// ftsQuery returns the FTS5 query that matches all terms of the text, where
+// each term is quoted to avoid interpreting the text as FTS5 query syntax.
+func ftsQuery(text string) string {
+	terms := strings.Fields(text)
+	for i, t := range terms {
+		terms[i] = `"` + strings.ReplaceAll(t, `"`, `""`) + `"`
+	}
+	return strings.Join(terms, " ")
+}
+
+// quoteIdent quotes the identifier for the given dialect.
+func quoteIdent(d, s string) string {
+	b := &sql.Builder{}
+	b.SetDialect(d)
+	return b.Ident(s).String()
+}
+
+// quoteIdents quotes the identifiers for the given dialect.
+func quoteIdents(d string, idents []string) []string {
+	quoted := make([]string, len(idents))
+	for i, s := range idents {
+		quoted[i] = quoteIdent(d, s)
+	}
+	return quoted
+}
+
+// cteTablesKey is the context key of the tables of the common table
+// expressions that queries select from (e.g. "<table>_window").
+type cteTablesKey struct{}
+
+// withCTETable returns a copy of ctx where the common table expression is
+// mapped to the table of the query it was built for.
+func withCTETable(ctx context.Context, cte, table string) context.Context {
+	tables := map[string]string{cte: table}
+	if prev, ok := ctx.Value(cteTablesKey{}).(map[string]string); ok {
+		for k, v := range prev {
+			if _, ok := tables[k]; !ok {
+				tables[k] = v
+			}
+		}
+	}
+	return context.WithValue(ctx, cteTablesKey{}, tables)
+}
+
+// searchTable returns the name of the table of the selector, where queries with
+// joins or windows select from common table expressions of their tables.
+func searchTable(s *sql.Selector) string {
+	name := s.TableName()
+	if tables, ok := s.Context().Value(cteTablesKey{}).(map[string]string); ok {
+		if t, ok := tables[name]; ok {
+			return t
+		}
+	}
+	return name
+}
+
+// Search applies the full-text search predicate on the given columns, that must match
+// the columns of a full-text index of the table (see AddFullTextIndex). The query is
+// a plain text whose terms are matched in natural language mode in MySQL, and must
+// all be present in PostgreSQL and SQLite.
+//
+//	client.Table("posts").Query().
+//		Where(Search([]string{"title", "body"}, "window functions")).
+//		All(ctx)
+func Search(columns []string, query string) Predicate {
+	return Predicate(func(s *sql.Selector) {
+		if strings.TrimSpace(query) == "" {
+			s.AddError(&ValidationError{Name: "query", err: errors.New("ent: empty full-text search query")})
+			return
+		}
+		switch s.Dialect() {
+		case dialect.MySQL:
+			s.Where(sql.P(func(b *sql.Builder) {
+				b.WriteString("MATCH (" + strings.Join(s.Columns(columns...), ", ") + ") AGAINST (")
+				b.Arg(query).WriteString(" IN NATURAL LANGUAGE MODE)")
+			}))
+		case dialect.Postgres:
+			s.Where(sql.P(func(b *sql.Builder) {
+				b.WriteString(tsVector(s.Columns(columns...)) + " @@ plainto_tsquery(" + quoteString(tsConfig) + ", ")
+				b.Arg(query).WriteByte(')')
+			}))
+		default:
+			fts := quoteIdent(s.Dialect(), ftsTable(searchTable(s), columns))
+			s.Where(sql.P(func(b *sql.Builder) {
+				b.WriteString(s.C(FieldID) + " IN (SELECT rowid FROM " + fts + " WHERE " + fts + " MATCH ")
+				b.Arg(ftsQuery(query)).WriteByte(')')
+			}))
+		}
+	})
+}
+
+// OrderBySearch orders the rows by their relevance to the full-text search query on
+// the given columns, most relevant first. It is usually used with the Search predicate.
+// Since the relevance is not part of the selected columns, the query is not unique
+// (i.e. SELECT DISTINCT) unless configured otherwise with Unique.
+func (dq *DQuery) OrderBySearch(columns []string, query string) *DQuery {
+	if dq.unique == nil {
+		dq.Unique(false)
+	}
+	return dq.Order(func(s *sql.Selector) {
+		if strings.TrimSpace(query) == "" {
+			return
+		}
+		switch s.Dialect() {
+		case dialect.MySQL:
+			s.OrderExpr(sql.ExprFunc(func(b *sql.Builder) {
+				b.WriteString("MATCH (" + strings.Join(s.Columns(columns...), ", ") + ") AGAINST (")
+				b.Arg(query).WriteString(" IN NATURAL LANGUAGE MODE) DESC")
+			}))
+		case dialect.Postgres:
+			s.OrderExpr(sql.ExprFunc(func(b *sql.Builder) {
+				b.WriteString("ts_rank(" + tsVector(s.Columns(columns...)) + ", plainto_tsquery(" + quoteString(tsConfig) + ", ")
+				b.Arg(query).WriteString(")) DESC")
+			}))
+		default:
+			// The rank of FTS5 is lower for more relevant rows.
+			fts := quoteIdent(s.Dialect(), ftsTable(searchTable(s), columns))
+			s.OrderExpr(sql.ExprFunc(func(b *sql.Builder) {
+				b.WriteString("(SELECT rank FROM " + fts + " WHERE " + fts + " MATCH ")
+				b.Arg(ftsQuery(query)).WriteString(" AND rowid = " + s.C(FieldID) + ")")
+			}))
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package dent
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	atlas "ariga.io/atlas/sql/schema"
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+)
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+	client := openTest(t)
+	if _, err := client.ExecRaw(ctx, "CREATE VIRTUAL TABLE probe USING fts5(a)"); err != nil {
+		t.Skipf("fts5 is not supported by the sqlite driver (build with -tags sqlite_fts5): %v", err)
+	}
+	articles := NewTable("articles")
+	articles.AddColumn(&schema.Column{Name: "title", Type: field.TypeString})
+	articles.AddColumn(&schema.Column{Name: "body", Type: field.TypeString})
+	AddFullTextIndex(articles, "articles_search", "title", "body")
+	// A user table whose name looks like an FTS table.
+	archive := NewTable("archive_fts")
+	archive.AddColumn(&schema.Column{Name: "body", Type: field.TypeString})
+	for _, table := range []*schema.Table{articles, archive} {
+		if err := client.Schema.Create(ctx, table); err != nil {
+			t.Fatalf("failed creating table: %v", err)
+		}
+		client.AddTable(table)
+	}
+	for _, a := range [][2]string{{"window functions", "rank rows"}, {"search", "full-text search"}, {"joins", "search joins"}} {
+		client.Table("articles").Create().SetValue("title", a[0]).SetValue("body", a[1]).SaveX(ctx)
+	}
+	columns := []string{"title", "body"}
+	ids := client.Table("articles").Query().Where(Search(columns, "search")).OrderBySearch(columns, "search").IDsX(ctx)
+	if len(ids) != 2 || ids[0] != 2 {
+		t.Errorf("unexpected search results: %v", ids)
+	}
+	// Queries with windows select from common table expressions.
+	nodes, err := client.Table("articles").Query().
+		Window(RowNumber().Asc(FieldID).As("pos")).
+		Qualify(Search(columns, "joins")).
+		All(ctx)
+	if err != nil || len(nodes) != 1 || nodes[0].ID != 3 || nodes[0].Row["pos"] != int64(3) {
+		t.Errorf("unexpected search results with windows: %v, %v", nodes, err)
+	}
+	tables, err := client.Schema.Inspect(ctx)
+	if err != nil {
+		t.Fatalf("failed inspecting schema: %v", err)
+	}
+	var names []string
+	for _, t := range tables {
+		names = append(names, t.Name)
+	}
+	sort.Strings(names)
+	if got := strings.Join(names, ","); got != "archive_fts,articles" {
+		t.Errorf("unexpected inspected tables: %s", got)
+	}
+}
+
+func TestKeepFullText(t *testing.T) {
+	table := NewTable("articles")
+	table.AddColumn(&schema.Column{Name: "title", Type: field.TypeString})
+	AddFullTextIndex(table, "articles_search", "title")
+	hook := keepFullText([]*schema.Table{table})
+	current := atlas.NewTable("articles")
+	other := atlas.NewTable("users")
+	differ := hook(schema.DiffFunc(func(_, _ *atlas.Schema) ([]atlas.Change, error) {
+		return []atlas.Change{
+			&atlas.ModifyTable{T: current, Changes: []atlas.Change{
+				&atlas.DropIndex{I: atlas.NewIndex("articles_search")},
+				&atlas.DropIndex{I: atlas.NewIndex("articles_title")},
+			}},
+			&atlas.ModifyTable{T: current, Changes: []atlas.Change{
+				&atlas.DropIndex{I: atlas.NewIndex("articles_search")},
+			}},
+			&atlas.ModifyTable{T: other, Changes: []atlas.Change{
+				&atlas.DropIndex{I: atlas.NewIndex("articles_search")},
+			}},
+		}, nil
+	}))
+	changes, err := differ.Diff(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("unexpected changes: %v", changes)
+	}
+	if m := changes[0].(*atlas.ModifyTable); len(m.Changes) != 1 || m.Changes[0].(*atlas.DropIndex).I.Name != "articles_title" {
+		t.Errorf("unexpected changes of the full-text table: %v", m.Changes)
+	}
+	if m := changes[1].(*atlas.ModifyTable); m.T != other || len(m.Changes) != 1 {
+		t.Errorf("unexpected changes of other tables: %v", m.Changes)
+	}
+}
+
+func TestAddFullTextIndex(t *testing.T) {
+	table := NewTable("articles")
+	table.AddColumn(&schema.Column{Name: "title", Type: field.TypeString})
+	table.AddColumn(&schema.Column{Name: "views", Type: field.TypeInt})
+	for _, columns := range [][]string{{"title", "views"}, {"nope"}, nil} {
+		func() {
+			defer func() {
+				if err, ok := recover().(error); !ok || !IsValidationError(err) {
+					t.Errorf("expected validation error of full-text index on %v, got: %v", columns, err)
+				}
+			}()
+			AddFullTextIndex(table, "articles_search", columns...)
+		}()
+	}
+	if len(table.Indexes) != 0 {
+		t.Errorf("unexpected indexes: %v", table.Indexes)
+	}
+	def := &TableDef{
+		Name:    "articles",
+		Columns: []*ColumnDef{{Name: "views", Type: "int"}},
+		Indexes: []*IndexDef{{Name: "articles_search", FullText: true, Columns: []string{"views"}}},
+	}
+	if _, err := def.Table(); !IsValidationError(err) {
+		t.Errorf("expected validation error of full-text index on int column, got: %v", err)
+	}
+}
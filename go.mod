@@ -3,13 +3,15 @@ module github.com/go-kenka/dent
 go 1.18
 
 require (
+	ariga.io/atlas v0.5.1-0.20220717122844-8593d7eb1a8e
 	entgo.io/ent v0.11.2
 	github.com/go-sql-driver/mysql v1.6.0
 	github.com/jinzhu/copier v0.3.5
+	github.com/lib/pq v1.10.7
+	github.com/mattn/go-sqlite3 v1.14.16
 )
 
 require (
-	ariga.io/atlas v0.5.1-0.20220717122844-8593d7eb1a8e // indirect
 	github.com/agext/levenshtein v1.2.1 // indirect
 	github.com/apparentlymart/go-textseg/v13 v13.0.0 // indirect
 	github.com/go-openapi/inflect v0.19.0 // indirect
@@ -0,0 +1,156 @@
+package dent
+
+import (
+	"context"
+	stdsql "database/sql"
+	"fmt"
+	"strings"
+
+	"ariga.io/atlas/sql/mysql"
+	"ariga.io/atlas/sql/postgres"
+	atlas "ariga.io/atlas/sql/schema"
+	"ariga.io/atlas/sql/sqlite"
+	"entgo.io/ent/dialect"
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+)
+
+// Inspect returns the definitions of the tables that exist in the database.
+func (s *Schema) Inspect(ctx context.Context) ([]*schema.Table, error) {
+	var (
+		err error
+		drv interface {
+			InspectSchema(context.Context, string, *atlas.InspectOptions) (*atlas.Schema, error)
+		}
+		conn = &execQuerier{ExecQuerier: s.drv}
+	)
+	switch s.drv.Dialect() {
+	case dialect.MySQL:
+		drv, err = mysql.Open(conn)
+	case dialect.Postgres:
+		drv, err = postgres.Open(conn)
+	case dialect.SQLite:
+		drv, err = sqlite.Open(conn)
+	default:
+		err = fmt.Errorf("unsupported dialect %q", s.drv.Dialect())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ent/migrate: %w", err)
+	}
+	realm, err := drv.InspectSchema(ctx, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("ent/migrate: inspecting schema: %w", err)
+	}
+	// FTS5 tables of full-text indexes in SQLite are not user tables.
+	var fts map[string]bool
+	if s.drv.Dialect() == dialect.SQLite {
+		if fts, err = ftsTables(ctx, s.drv); err != nil {
+			return nil, fmt.Errorf("ent/migrate: inspecting full-text tables: %w", err)
+		}
+	}
+	tables := make([]*schema.Table, 0, len(realm.Tables))
+	for _, t := range realm.Tables {
+		if t.Name == schema.TypeTable || fts[t.Name] {
+			continue
+		}
+		table, err := entTable(t)
+		if err != nil {
+			return nil, fmt.Errorf("ent/migrate: %w", err)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// entTable converts an inspected table to its schema table.
+func entTable(t *atlas.Table) (*schema.Table, error) {
+	table := schema.NewTable(t.Name)
+	for _, c := range t.Columns {
+		col := &schema.Column{Name: c.Name, Nullable: c.Type.Null}
+		switch typ := c.Type.Type.(type) {
+		case *atlas.BoolType:
+			col.Type = field.TypeBool
+		case *atlas.IntegerType:
+			col.Type = field.TypeInt
+			switch {
+			case typ.Unsigned && strings.Contains(typ.T, "big"):
+				col.Type = field.TypeUint64
+			case typ.Unsigned:
+				col.Type = field.TypeUint
+			case strings.Contains(typ.T, "big"):
+				col.Type = field.TypeInt64
+			}
+		case *atlas.FloatType, *atlas.DecimalType:
+			col.Type = field.TypeFloat64
+		case *atlas.StringType:
+			col.Type, col.Size = field.TypeString, int64(typ.Size)
+		case *atlas.EnumType:
+			col.Type, col.Enums = field.TypeEnum, typ.Values
+		case *atlas.BinaryType:
+			col.Type = field.TypeBytes
+		case *atlas.TimeType:
+			col.Type = field.TypeTime
+		case *atlas.JSONType:
+			col.Type = field.TypeJSON
+		case *postgres.UUIDType, *sqlite.UUIDType:
+			col.Type = field.TypeUUID
+		default:
+			col.Type = field.TypeOther
+		}
+		if lit, ok := c.Default.(*atlas.Literal); ok {
+			if err := col.ScanDefault(strings.Trim(lit.V, `'"`)); err != nil {
+				return nil, err
+			}
+		}
+		table.AddColumn(col)
+	}
+	if pk := t.PrimaryKey; pk != nil {
+		for _, part := range pk.Parts {
+			if part.C == nil {
+				continue
+			}
+			c, _ := table.Column(part.C.Name)
+			c.Key = schema.PrimaryKey
+			c.Increment = len(pk.Parts) == 1 && c.Type.Integer()
+			table.PrimaryKey = append(table.PrimaryKey, c)
+		}
+	}
+	for _, idx := range t.Indexes {
+		columns := make([]string, 0, len(idx.Parts))
+		for _, part := range idx.Parts {
+			// Expression indexes are not supported by schema tables.
+			if part.C == nil {
+				columns = nil
+				break
+			}
+			columns = append(columns, part.C.Name)
+		}
+		if len(columns) > 0 {
+			table.AddIndex(idx.Name, idx.Unique, columns)
+		}
+	}
+	return table, nil
+}
+
+// execQuerier adapts a dialect.ExecQuerier to the interface used by the
+// inspection drivers.
+type execQuerier struct{ dialect.ExecQuerier }
+
+// QueryContext implements the atlas.ExecQuerier interface.
+func (e *execQuerier) QueryContext(ctx context.Context, query string, args ...interface{}) (*stdsql.Rows, error) {
+	rows := &sql.Rows{}
+	if err := e.ExecQuerier.Query(ctx, query, args, rows); err != nil {
+		return nil, err
+	}
+	return rows.ColumnScanner.(*stdsql.Rows), nil
+}
+
+// ExecContext implements the atlas.ExecQuerier interface.
+func (e *execQuerier) ExecContext(ctx context.Context, query string, args ...interface{}) (stdsql.Result, error) {
+	var r stdsql.Result
+	if err := e.ExecQuerier.Exec(ctx, query, args, &r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
@@ -0,0 +1,150 @@
+package dent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+)
+
+// JoinOn builds the condition of a join, from the table of the query and the joined table.
+type JoinOn func(t, joined *sql.SelectTable) *sql.Predicate
+
+// On returns the join condition that matches the column of the query
+// table with the column of the joined table.
+//
+//	client.Table("posts").Query().
+//		Join("users", On("user_id", "id")).
+//		Where(StringEQ("users.name", "a8m"))
+func On(column, joinedColumn string) JoinOn {
+	return func(t, joined *sql.SelectTable) *sql.Predicate {
+		return sql.ColumnsEQ(t.C(column), joined.C(joinedColumn))
+	}
+}
+
+// join is a join step of a query.
+type join struct {
+	table string
+	on    JoinOn
+	left  bool
+}
+
+// Join adds an inner join with the given registered table to the query. The columns
+// of the joined table are added to the rows with the name of the table as prefix
+// (e.g. "users.name"), and can be used in predicates, ordering and selections.
+func (dq *DQuery) Join(table string, on JoinOn) *DQuery {
+	dq.joins = append(dq.joins, &join{table: table, on: on})
+	return dq
+}
+
+// LeftJoin is like Join, but keeps the rows that have no matching rows in
+// the joined table. The joined columns of these rows are zero values.
+func (dq *DQuery) LeftJoin(table string, on JoinOn) *DQuery {
+	dq.joins = append(dq.joins, &join{table: table, on: on, left: true})
+	return dq
+}
+
+// joinedTable returns the registered schema of the joined table.
+func (dq *DQuery) joinedTable(name string) (*schema.Table, bool) {
+	if dq.table.client == nil {
+		return nil, false
+	}
+	t, ok := dq.table.client.tmap[name]
+	return t, ok
+}
+
+// hasColumn reports if the column belongs to the table of the query, to one of
+// its joined tables, or is the result of one of its window functions.
+func (dq *DQuery) hasColumn(column string) bool {
+	_, ok := dq.column(column)
+	return ok
+}
+
+// column returns the schema of the column of the query, where the results of
+// window functions are typed as the values they are scanned into.
+func (dq *DQuery) column(column string) (*schema.Column, bool) {
+	if c, ok := dq.table.Column(column); ok {
+		return c, true
+	}
+	if w, ok := dq.window(column); ok {
+		switch {
+		case w.ranking:
+			return &schema.Column{Name: column, Type: field.TypeInt}, true
+		case w.field != "":
+			c, ok := dq.table.Column(w.field)
+			if !ok {
+				return nil, false
+			}
+			return &schema.Column{Name: column, Type: c.Type, Nullable: true}, true
+		default:
+			return &schema.Column{Name: column, Type: field.TypeFloat64, Nullable: true}, true
+		}
+	}
+	for _, j := range dq.joins {
+		if name := strings.TrimPrefix(column, j.table+"."); name != column {
+			t, ok := dq.joinedTable(j.table)
+			if !ok {
+				return nil, false
+			}
+			return t.Column(name)
+		}
+	}
+	return nil, false
+}
+
+// columns returns the default columns of the query, including the prefixed columns
+// of the joined tables, and the names of the window functions.
+func (dq *DQuery) columns() []string {
+	columns := dq.table.GetColumns()
+	for _, j := range dq.joins {
+		if t, ok := dq.joinedTable(j.table); ok {
+			for _, c := range t.Columns {
+				columns = append(columns, j.table+"."+c.Name)
+			}
+		}
+	}
+	for _, w := range dq.windows {
+		columns = append(columns, w.as)
+	}
+	return columns
+}
+
+// joinQuery returns the selector of the joined tables. The join is defined as a
+// common table expression that selects the columns of all tables, to make joined
+// columns addressable by their prefixed names in predicates and orderings.
+//
+//	WITH `posts_join` AS (SELECT `posts`.`id`, ..., `users`.`name` AS `users.name` FROM `posts` JOIN `users` ...)
+//	SELECT ... FROM `posts_join`
+func (dq *DQuery) joinQuery(ctx context.Context, from *sql.Selector) (*sql.Selector, error) {
+	builder := sql.Dialect(dq.table.driver.Dialect())
+	t := builder.Table(dq.table.Name)
+	if from == nil {
+		from = builder.Select().From(t)
+	} else {
+		t = from.Table()
+	}
+	columns := from.Columns(dq.table.GetColumns()...)
+	for _, j := range dq.joins {
+		jt, ok := dq.joinedTable(j.table)
+		if !ok {
+			return nil, &ValidationError{Name: j.table, err: fmt.Errorf("ent: unknown table %q for join", j.table)}
+		}
+		joined := builder.Table(jt.Name).As(jt.Name)
+		if j.left {
+			from.LeftJoin(joined)
+		} else {
+			from.Join(joined)
+		}
+		from.OnP(j.on(t, joined))
+		for _, c := range jt.Columns {
+			columns = append(columns, sql.As(joined.C(c.Name), jt.Name+"."+c.Name))
+		}
+	}
+	from.Select(columns...)
+	with := sql.With(dq.table.Name + "_join").As(from)
+	ctx = withCTETable(ctx, with.Name(), dq.table.Name)
+	return builder.Select().From(builder.Table(with.Name())).Prefix(with).WithContext(ctx), nil
+}
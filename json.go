@@ -0,0 +1,325 @@
+package dent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"entgo.io/ent/dialect"
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/sqljson"
+	"entgo.io/ent/schema/field"
+)
+
+// JSONHasKey applies the predicate that checks that the JSON field has a non-null
+// value at the given path. Paths use the dot format, for example "a.b[2].c".
+func JSONHasKey(field, path string) Predicate {
+	return jsonPredicate(field, path, func(column string, opts ...sqljson.Option) *sql.Predicate {
+		return sqljson.HasKey(column, opts...)
+	})
+}
+
+// JSONValueEQ applies the EQ predicate on the value at the path of the JSON field.
+func JSONValueEQ(field, path string, v interface{}) Predicate {
+	return jsonPredicate(field, path, func(column string, opts ...sqljson.Option) *sql.Predicate {
+		return sqljson.ValueEQ(column, v, opts...)
+	})
+}
+
+// JSONValueNEQ applies the NEQ predicate on the value at the path of the JSON field.
+func JSONValueNEQ(field, path string, v interface{}) Predicate {
+	return jsonPredicate(field, path, func(column string, opts ...sqljson.Option) *sql.Predicate {
+		return sqljson.ValueNEQ(column, v, opts...)
+	})
+}
+
+// JSONValueGT applies the GT predicate on the value at the path of the JSON field.
+func JSONValueGT(field, path string, v interface{}) Predicate {
+	return jsonPredicate(field, path, func(column string, opts ...sqljson.Option) *sql.Predicate {
+		return sqljson.ValueGT(column, v, opts...)
+	})
+}
+
+// JSONValueGTE applies the GTE predicate on the value at the path of the JSON field.
+func JSONValueGTE(field, path string, v interface{}) Predicate {
+	return jsonPredicate(field, path, func(column string, opts ...sqljson.Option) *sql.Predicate {
+		return sqljson.ValueGTE(column, v, opts...)
+	})
+}
+
+// JSONValueLT applies the LT predicate on the value at the path of the JSON field.
+func JSONValueLT(field, path string, v interface{}) Predicate {
+	return jsonPredicate(field, path, func(column string, opts ...sqljson.Option) *sql.Predicate {
+		return sqljson.ValueLT(column, v, opts...)
+	})
+}
+
+// JSONValueLTE applies the LTE predicate on the value at the path of the JSON field.
+func JSONValueLTE(field, path string, v interface{}) Predicate {
+	return jsonPredicate(field, path, func(column string, opts ...sqljson.Option) *sql.Predicate {
+		return sqljson.ValueLTE(column, v, opts...)
+	})
+}
+
+// JSONContains applies the predicate that checks that the array at the path
+// of the JSON field contains the given value.
+func JSONContains(field, path string, v interface{}) Predicate {
+	return jsonPredicate(field, path, func(column string, opts ...sqljson.Option) *sql.Predicate {
+		return sqljson.ValueContains(column, v, opts...)
+	})
+}
+
+// JSONLenEQ applies the predicate that checks the length of the array at the path of the JSON field.
+func JSONLenEQ(field, path string, n int) Predicate {
+	return jsonPredicate(field, path, func(column string, opts ...sqljson.Option) *sql.Predicate {
+		return sqljson.LenEQ(column, n, opts...)
+	})
+}
+
+// jsonPredicate returns the predicate built by fn for the path of the JSON field.
+func jsonPredicate(field, path string, fn func(string, ...sqljson.Option) *sql.Predicate) Predicate {
+	return Predicate(func(s *sql.Selector) {
+		p, err := parseJSONPath(path)
+		if err != nil {
+			s.AddError(&ValidationError{Name: field, err: err})
+			return
+		}
+		s.Where(fn(s.C(field), sqljson.Path(p...)))
+	})
+}
+
+// OrderByJSON orders the rows by the value at the path of the JSON field. Since
+// the value is not part of the selected columns, the query is not unique (i.e.
+// SELECT DISTINCT) unless configured otherwise with Unique.
+//
+//	client.Table("users").Query().
+//		OrderByJSON("profile", "address.city", false).
+//		All(ctx)
+func (dq *DQuery) OrderByJSON(field, path string, desc bool) *DQuery {
+	if dq.unique == nil {
+		dq.Unique(false)
+	}
+	return dq.Order(func(s *sql.Selector) {
+		p, err := parseJSONPath(path)
+		if err != nil {
+			s.AddError(&ValidationError{Name: field, err: err})
+			return
+		}
+		s.OrderExpr(sql.ExprFunc(func(b *sql.Builder) {
+			sqljson.ValuePath(b, s.C(field), sqljson.Path(p...))
+			if desc {
+				b.WriteString(" DESC")
+			}
+		}))
+	})
+}
+
+// parseJSONPath parses the path of a JSON value in the dot format.
+func parseJSONPath(path string) ([]string, error) {
+	p, err := sqljson.ParsePath(path)
+	if err != nil {
+		return nil, fmt.Errorf("ent: invalid JSON path %q: %w", path, err)
+	}
+	return p, nil
+}
+
+// jsonExpr is a partial update of a JSON column. The expression is built
+// for the dialect of the statement every time it is queried, and applies
+// on the result of the previous partial update of the column, if any.
+type jsonExpr struct {
+	sql.Builder
+	column string
+	prev   *jsonExpr
+	// err is the validation error of the update.
+	err error
+	fn  func(b *sql.Builder, doc func(*sql.Builder))
+}
+
+// Query returns the query representation of the expression.
+func (e *jsonExpr) Query() (string, []interface{}) {
+	b := &sql.Builder{}
+	b.SetDialect(e.Dialect())
+	b.SetTotal(e.Total())
+	e.fn(b, e.doc)
+	return b.Query()
+}
+
+// doc writes the JSON document that the expression applies on.
+func (e *jsonExpr) doc(b *sql.Builder) {
+	if e.prev == nil {
+		b.Ident(e.column)
+		return
+	}
+	b.Join(e.prev)
+}
+
+// Err returns the error of the expression, or of the previous updates of the column.
+func (e *jsonExpr) Err() error {
+	if e.err != nil {
+		return e.err
+	}
+	if err := e.Builder.Err(); err != nil {
+		return err
+	}
+	if e.prev != nil {
+		return e.prev.Err()
+	}
+	return nil
+}
+
+// SetJSONPath sets the value at the path of the JSON field, without replacing the
+// rest of the document. The value is marshaled to JSON. Missing keys are created,
+// but only at the last step of the path in PostgreSQL.
+//
+//	client.Table("users").Update().
+//		SetJSONPath("profile", "address.city", "Tel Aviv").
+//		Save(ctx)
+func (m *DMutation) SetJSONPath(name, path string, v interface{}) {
+	buf, err := json.Marshal(v)
+	m.jsonUpdate(name, path, false, err, func(b *sql.Builder, doc func(*sql.Builder), path []string) {
+		switch b.Dialect() {
+		case dialect.MySQL:
+			b.WriteString("JSON_SET(COALESCE(")
+			doc(b)
+			b.WriteString(", JSON_OBJECT()), ").Arg(mysqlJSONPath(path)).WriteString(", CAST(").Arg(string(buf)).WriteString(" AS JSON))")
+		case dialect.Postgres:
+			b.WriteString("JSONB_SET(COALESCE(CAST(")
+			doc(b)
+			b.WriteString(" AS JSONB), '{}'::jsonb), CAST(").Arg(pgJSONPath(path)).WriteString(" AS TEXT[]), CAST(").Arg(string(buf)).WriteString(" AS JSONB), true)")
+		default:
+			b.WriteString("JSON_SET(COALESCE(")
+			doc(b)
+			b.WriteString(", '{}'), ").Arg(mysqlJSONPath(path)).WriteString(", JSON(").Arg(string(buf)).WriteString("))")
+		}
+	})
+}
+
+// AppendJSON appends the values to the array at the path of the JSON field, where
+// an empty path is the root of the document. Missing or NULL arrays are created.
+// The values are marshaled to JSON.
+//
+//	client.Table("users").UpdateOneID(id).
+//		AppendJSON("profile", "tags", "admin", "owner").
+//		Save(ctx)
+func (m *DMutation) AppendJSON(name, path string, values ...interface{}) {
+	if values == nil {
+		values = []interface{}{}
+	}
+	buf, err := json.Marshal(values)
+	elems := make([]string, len(values))
+	for i := range values {
+		if err != nil {
+			break
+		}
+		var elem []byte
+		elem, err = json.Marshal(values[i])
+		elems[i] = string(elem)
+	}
+	m.jsonUpdate(name, path, true, err, func(b *sql.Builder, doc func(*sql.Builder), path []string) {
+		// array writes the array at the path, or an empty array if it is missing.
+		array := func(empty string) {
+			if len(path) == 0 {
+				b.WriteString("COALESCE(")
+				doc(b)
+			} else {
+				b.WriteString("COALESCE(JSON_EXTRACT(")
+				doc(b)
+				b.WriteString(", ").Arg(mysqlJSONPath(path)).WriteString(")")
+			}
+			b.WriteString(", " + empty + ")")
+		}
+		switch d := b.Dialect(); {
+		case d == dialect.Postgres && len(path) == 0:
+			b.WriteString("COALESCE(CAST(")
+			doc(b)
+			b.WriteString(" AS JSONB), '[]'::jsonb) || CAST(").Arg(string(buf)).WriteString(" AS JSONB)")
+		case d == dialect.Postgres:
+			b.WriteString("JSONB_SET(COALESCE(CAST(")
+			doc(b)
+			b.WriteString(" AS JSONB), '{}'::jsonb), CAST(").Arg(pgJSONPath(path)).WriteString(" AS TEXT[]), COALESCE(CAST(")
+			doc(b)
+			b.WriteString(" AS JSONB) #> CAST(").Arg(pgJSONPath(path)).WriteString(" AS TEXT[]), '[]'::jsonb) || CAST(").Arg(string(buf)).WriteString(" AS JSONB), true)")
+		case d == dialect.MySQL:
+			if len(path) > 0 {
+				b.WriteString("JSON_SET(COALESCE(")
+				doc(b)
+				b.WriteString(", JSON_OBJECT()), ").Arg(mysqlJSONPath(path)).WriteString(", ")
+				defer b.WriteString(")")
+			}
+			b.WriteString("JSON_MERGE_PRESERVE(")
+			array("JSON_ARRAY()")
+			b.WriteString(", CAST(").Arg(string(buf)).WriteString(" AS JSON))")
+		default:
+			if len(path) > 0 {
+				b.WriteString("JSON_SET(COALESCE(")
+				doc(b)
+				b.WriteString(", '{}'), ").Arg(mysqlJSONPath(path)).WriteString(", ")
+				defer b.WriteString(")")
+			}
+			b.WriteString("JSON_INSERT(")
+			array("'[]'")
+			for _, elem := range elems {
+				b.WriteString(", '$[#]', JSON(").Arg(elem).WriteString(")")
+			}
+			b.WriteString(")")
+		}
+	})
+}
+
+// jsonUpdate adds the partial update of the JSON field to the mutation. The
+// path can be empty only for updates that apply on the root of the document.
+func (m *DMutation) jsonUpdate(name, path string, root bool, err error, fn func(*sql.Builder, func(*sql.Builder), []string)) {
+	col, ok := m.table.Column(name)
+	if !ok {
+		return
+	}
+	e := &jsonExpr{column: name}
+	if prev, ok := m.data[name].(*jsonExpr); ok {
+		e.prev = prev
+	}
+	var p []string
+	if err == nil {
+		p, err = parseJSONPath(path)
+	}
+	switch {
+	case err != nil:
+	case col.Type != field.TypeJSON:
+		err = fmt.Errorf("ent: field %q is not a JSON field", name)
+	case len(p) == 0 && !root:
+		err = fmt.Errorf("ent: missing JSON path for field %q", name)
+	}
+	if err != nil {
+		e.err = &ValidationError{Name: name, err: err}
+	}
+	e.fn = func(b *sql.Builder, doc func(*sql.Builder)) {
+		fn(b, doc, p)
+	}
+	m.data[name] = e
+	delete(m.added, name)
+}
+
+// mysqlJSONPath returns the path in the format of MySQL and SQLite. For example, `$.a[1].b`.
+func mysqlJSONPath(path []string) string {
+	var b strings.Builder
+	b.WriteByte('$')
+	for _, p := range path {
+		if !strings.HasPrefix(p, "[") {
+			b.WriteByte('.')
+		}
+		b.WriteString(p)
+	}
+	return b.String()
+}
+
+// pgJSONPath returns the path as a PostgreSQL text array. For example, `{"a","1","b"}`.
+func pgJSONPath(path []string) string {
+	elems := make([]string, len(path))
+	for i, p := range path {
+		if strings.HasPrefix(p, "[") && strings.HasSuffix(p, "]") {
+			p = p[1 : len(p)-1]
+		}
+		p = strings.Trim(p, `"`)
+		elems[i] = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(p) + `"`
+	}
+	return "{" + strings.Join(elems, ",") + "}"
+}
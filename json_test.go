@@ -0,0 +1,87 @@
+package dent
+
+import (
+	"context"
+	"testing"
+
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+)
+
+func TestJSONPathUpdate(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("docs")
+	table.AddColumn(&schema.Column{Name: "name", Type: field.TypeString})
+	table.AddColumn(&schema.Column{Name: "meta", Type: field.TypeJSON, Nullable: true})
+	client := openTest(t, table)
+	docs := client.Table("docs")
+	a := docs.Create().SetValue("name", "a").SetValue("meta", map[string]interface{}{"rank": 2, "tags": []string{"x"}}).SaveX(ctx)
+	b := docs.Create().SetValue("name", "b").SetValue("meta", map[string]interface{}{"rank": 1}).SaveX(ctx)
+	docs.Create().SetValue("name", "c").SaveX(ctx)
+
+	// Updates apply on the previous updates of the column, and create missing keys.
+	docs.Update().
+		Where(ID(a.ID)).
+		SetJSONPath("meta", "owner.name", "a8m").
+		AppendJSON("meta", "tags", "y", "z").
+		ExecX(ctx)
+	if meta := docs.Query().Where(ID(a.ID)).OnlyX(ctx).Row["meta"]; meta != `{"rank":2,"tags":["x","y","z"],"owner":{"name":"a8m"}}` {
+		t.Errorf("unexpected json value: %v", meta)
+	}
+	// Missing arrays are created.
+	docs.UpdateOneID(b.ID).SetJSONPath("meta", "rank", 3).AppendJSON("meta", "tags", "x").ExecX(ctx)
+	if meta := docs.Query().Where(ID(b.ID)).OnlyX(ctx).Row["meta"]; meta != `{"rank":3,"tags":["x"]}` {
+		t.Errorf("unexpected json value: %v", meta)
+	}
+
+	names := func(q *DQuery) []string {
+		var names []string
+		for _, n := range q.AllX(ctx) {
+			names = append(names, n.Row["name"].(string))
+		}
+		return names
+	}
+	for _, tt := range []struct {
+		name string
+		p    Predicate
+		want []string
+	}{
+		{name: "contains", p: JSONContains("meta", "tags", "x"), want: []string{"a", "b"}},
+		{name: "has key", p: JSONHasKey("meta", "owner.name"), want: []string{"a"}},
+		{name: "value eq", p: JSONValueEQ("meta", "rank", 3), want: []string{"b"}},
+		{name: "len eq", p: JSONLenEQ("meta", "tags", 3), want: []string{"a"}},
+	} {
+		if got := names(docs.Query().Where(tt.p).Order(Asc(FieldID))); !equalStrings(got, tt.want) {
+			t.Errorf("%s: unexpected rows: %v", tt.name, got)
+		}
+	}
+	if got := names(docs.Query().Where(JSONHasKey("meta", "rank")).OrderByJSON("meta", "rank", true)); !equalStrings(got, []string{"b", "a"}) {
+		t.Errorf("unexpected order: %v", got)
+	}
+
+	for _, tt := range []struct {
+		name string
+		u    *DUpdateOne
+	}{
+		{name: "not json", u: docs.UpdateOneID(a.ID).SetJSONPath("name", "a", 1)},
+		{name: "missing path", u: docs.UpdateOneID(a.ID).SetJSONPath("meta", "", 1)},
+		{name: "invalid path", u: docs.UpdateOneID(a.ID).SetJSONPath("meta", "a[x]", 1)},
+		{name: "invalid value", u: docs.UpdateOneID(a.ID).AppendJSON("meta", "tags", func() {})},
+	} {
+		if err := tt.u.Exec(ctx); !IsValidationError(err) {
+			t.Errorf("%s: expected validation error, got: %v", tt.name, err)
+		}
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
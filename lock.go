@@ -0,0 +1,60 @@
+package dent
+
+import (
+	"fmt"
+
+	"entgo.io/ent/dialect"
+	"entgo.io/ent/dialect/sql"
+)
+
+// rowLock is the locking clause of the rows of a query.
+type rowLock struct {
+	strength sql.LockStrength
+	opts     []sql.LockOption
+}
+
+// ForUpdate locks the rows that are selected by the query for updates, until the end of
+// the transaction of the client (see Client.Tx). The options set the action on rows that
+// are locked by other transactions, for example:
+//
+//	tx, err := client.Tx(ctx)
+//	jobs, err := tx.Client().Table("jobs").Query().
+//		Where(StringEQ("status", "pending")).
+//		Limit(10).
+//		ForUpdate(sql.WithLockAction(sql.SkipLocked)).
+//		All(ctx)
+//
+// Locked queries do not filter duplicate rows by default, and do not support joins and
+// windows. Row locking is not supported by SQLite, and its queries return an error.
+func (dq *DQuery) ForUpdate(opts ...sql.LockOption) *DQuery {
+	dq.lock = &rowLock{strength: sql.LockUpdate, opts: opts}
+	return dq
+}
+
+// ForShare locks the rows that are selected by the query for reads, until the end of the
+// transaction of the client. Other transactions can read the rows, but not update them.
+// The options are the same as in ForUpdate, for example sql.WithLockAction(sql.NoWait).
+func (dq *DQuery) ForShare(opts ...sql.LockOption) *DQuery {
+	dq.lock = &rowLock{strength: sql.LockShare, opts: opts}
+	return dq
+}
+
+// checkLock returns an error if the locking clause of the query is not supported.
+func (dq *DQuery) checkLock() error {
+	switch {
+	case dq.lock == nil:
+		return nil
+	case dq.table.driver.Dialect() == dialect.SQLite:
+		return &ValidationError{Name: "lock", err: fmt.Errorf("ent: row locking (FOR %s) is not supported by SQLite", dq.lock.strength)}
+	case len(dq.joins) > 0 || len(dq.windows) > 0:
+		return &ValidationError{Name: "lock", err: fmt.Errorf("ent: row locking (FOR %s) is not supported with joins and windows", dq.lock.strength)}
+	}
+	return nil
+}
+
+// lockRows adds the locking clause of the query to the selector, if any.
+func (dq *DQuery) lockRows(s *sql.Selector) {
+	if dq.lock != nil {
+		s.For(dq.lock.strength, dq.lock.opts...)
+	}
+}
@@ -0,0 +1,95 @@
+package dent
+
+import (
+	"context"
+	stdsql "database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"entgo.io/ent/dialect"
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+)
+
+func TestLockSQLite(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("jobs")
+	table.AddColumn(&schema.Column{Name: "status", Type: field.TypeString})
+	client := openTest(t, table)
+	jobs := client.Table("jobs")
+	jobs.Create().SetValue("status", "pending").SaveX(ctx)
+
+	tx, err := client.Tx(ctx)
+	if err != nil {
+		t.Fatalf("failed starting transaction: %v", err)
+	}
+	defer tx.Rollback()
+	for _, q := range []*DQuery{
+		tx.Client().Table("jobs").Query().ForUpdate(),
+		tx.Client().Table("jobs").Query().ForShare(sql.WithLockAction(sql.NoWait)),
+		// Locks are kept in clones.
+		jobs.Query().Where(StringEQ("status", "pending")).ForUpdate().Clone(),
+	} {
+		if _, err := q.All(ctx); !IsValidationError(err) || !strings.Contains(err.Error(), "SQLite") {
+			t.Errorf("expected validation error of row locking, got: %v", err)
+		}
+		if _, err := q.First(ctx); !IsValidationError(err) {
+			t.Errorf("expected validation error of row locking, got: %v", err)
+		}
+	}
+	if n := jobs.Query().CountX(ctx); n != 1 {
+		t.Errorf("unexpected count of unlocked query: %d", n)
+	}
+}
+
+func TestLockClause(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("jobs")
+	table.AddColumn(&schema.Column{Name: "status", Type: field.TypeString})
+	db, err := stdsql.Open("sqlite3", "file:TestLockClause?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("failed opening connection to sqlite: %v", err)
+	}
+	defer db.Close()
+	// The queries are built for MySQL, and fail on execution.
+	var queries []string
+	client := NewClient(Driver(sql.OpenDB(dialect.MySQL, db)), Debug(), Log(func(v ...interface{}) {
+		queries = append(queries, fmt.Sprint(v...))
+	}))
+	client.AddTable(table)
+	jobs := client.Table("jobs")
+
+	for _, tt := range []struct {
+		q    *DQuery
+		want string
+	}{
+		{q: jobs.Query().Where(StringEQ("status", "pending")).Limit(10).ForUpdate(sql.WithLockAction(sql.SkipLocked)), want: "LIMIT 10 FOR UPDATE SKIP LOCKED"},
+		{q: jobs.Query().ForShare(sql.WithLockAction(sql.NoWait)), want: "FOR SHARE NOWAIT"},
+	} {
+		queries = nil
+		if _, err := tt.q.All(ctx); IsValidationError(err) {
+			t.Errorf("unexpected validation error: %v", err)
+		}
+		if len(queries) != 1 || !strings.Contains(queries[0], tt.want+" args=") {
+			t.Errorf("expected locking clause %q in queries: %v", tt.want, queries)
+		}
+		// Locked rows are not filtered with DISTINCT.
+		if len(queries) == 1 && strings.Contains(queries[0], "DISTINCT") {
+			t.Errorf("unexpected distinct query: %s", queries[0])
+		}
+	}
+	for _, q := range []*DQuery{
+		jobs.Query().Join("workers", On("id", "job_id")).ForUpdate(),
+		jobs.Query().Window(RowNumber().As("n")).ForUpdate(),
+	} {
+		queries = nil
+		if _, err := q.All(ctx); !IsValidationError(err) || !strings.Contains(err.Error(), "row locking") {
+			t.Errorf("expected validation error of row locking, got: %v", err)
+		}
+		if len(queries) > 0 {
+			t.Errorf("unexpected queries: %v", queries)
+		}
+	}
+}
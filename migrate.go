@@ -3,6 +3,7 @@ package dent
 import (
 	"context"
 	"fmt"
+	"io"
 
 	"entgo.io/ent/dialect"
 	"entgo.io/ent/dialect/sql/schema"
@@ -44,9 +45,26 @@ func (s *Schema) Create(ctx context.Context, table *schema.Table, opts ...schema
 
 // Create creates all table resources using the given schema driver.
 func Create(ctx context.Context, s *Schema, tables []*schema.Table, opts ...schema.MigrateOption) error {
+	if s.drv.Dialect() != dialect.MySQL {
+		opts = append(opts, schema.WithDiffHook(keepFullText(tables)))
+	}
 	migrate, err := schema.NewMigrate(s.drv, opts...)
 	if err != nil {
 		return fmt.Errorf("ent/migrate: %w", err)
 	}
-	return migrate.Create(ctx, tables...)
+	if err := migrate.Create(ctx, migrateTables(s.drv.Dialect(), tables)...); err != nil {
+		return err
+	}
+	return createFullText(ctx, s.drv, tables)
+}
+
+// WriteTo writes the schema changes of the given table to w instead of running them
+// against the database. It can be used for printing the migration plan (dry-run).
+func (s *Schema) WriteTo(ctx context.Context, w io.Writer, table *schema.Table, opts ...schema.MigrateOption) error {
+	return WriteTo(ctx, s, w, []*schema.Table{table}, opts...)
+}
+
+// WriteTo writes the schema changes of all tables to w using the given schema driver.
+func WriteTo(ctx context.Context, s *Schema, w io.Writer, tables []*schema.Table, opts ...schema.MigrateOption) error {
+	return Create(ctx, &Schema{drv: &schema.WriteDriver{Driver: s.drv, Writer: w}}, tables, opts...)
 }
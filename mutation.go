@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
 	"sync"
 
 	"entgo.io/ent"
@@ -31,10 +32,17 @@ type DMutation struct {
 	typ           string
 	id            *int
 	data          map[string]ent.Value
+	added         map[string]ent.Value
 	clearedFields map[string]struct{}
 	done          bool
 	oldValue      func(context.Context) (*Dynamic, error)
-	predicates    []Predicate
+	// entity is the entity that the mutation was created
+	// from, and whose row was copied to the mutation.
+	entity     *Dynamic
+	predicates []Predicate
+	// err is the first error of the setters,
+	// that is returned when the mutation is saved.
+	err error
 }
 
 var _ ent.Mutation = (*DMutation)(nil)
@@ -49,6 +57,7 @@ func newDMutation(t *Table, op Op, opts ...dOption) *DMutation {
 		op:            op,
 		typ:           TypeDynamic,
 		data:          make(map[string]ent.Value),
+		added:         make(map[string]ent.Value),
 		clearedFields: make(map[string]struct{}),
 	}
 	for _, opt := range opts {
@@ -87,7 +96,11 @@ func withEntity(node *Dynamic) dOption {
 		}
 
 		m.id = &node.ID
-		m.data = node.Row
+		m.entity = node
+		// Copy the row, to not change the entity before the mutation is saved.
+		for k, v := range node.Row {
+			m.data[k] = v
+		}
 	}
 }
 
@@ -125,6 +138,9 @@ func withField(fields ...*schema.Column) dOption {
 // executed in a transaction (ent.Tx), a transactional client is returned.
 func (m DMutation) Client() *Client {
 	client := &Client{config: m.table.config}
+	if m.table.client != nil {
+		client.tmap = m.table.client.tmap
+	}
 	client.init()
 	return client
 }
@@ -135,7 +151,7 @@ func (m DMutation) Tx() (*Tx, error) {
 	if _, ok := m.table.driver.(*txDriver); !ok {
 		return nil, errors.New("ent: mutation is not running in a transaction")
 	}
-	tx := &Tx{config: m.table.config}
+	tx := &Tx{config: m.table.config, client: m.table.client}
 	tx.init()
 	return tx, nil
 }
@@ -160,6 +176,18 @@ func (m *DMutation) ID() (id int, exists bool) {
 func (m *DMutation) SetValue(field string, val interface{}) {
 	if m.table.HasColumn(field) {
 		m.data[field] = &val
+		delete(m.added, field)
+	}
+}
+
+// SetExpr sets the field to the result of the given SQL expression, that
+// is evaluated by the database on update. For example:
+//
+//	m.SetExpr("price", sql.Expr("price * 1.1"))
+func (m *DMutation) SetExpr(field string, expr sql.Querier) {
+	if m.table.HasColumn(field) {
+		m.data[field] = expr
+		delete(m.added, field)
 	}
 }
 
@@ -193,42 +221,83 @@ func (m *DMutation) OldValue(ctx context.Context, field string) (v interface{},
 
 // ClearEditorID clears the value of the "field" field.
 func (m *DMutation) ClearValue(field string) {
-	delete(m.data, field)
-	m.clearedFields[field] = struct{}{}
+	if m.table.HasColumn(field) {
+		delete(m.data, field)
+		delete(m.added, field)
+		m.clearedFields[field] = struct{}{}
+	}
 }
 
 // ResetName resets all changes to the "field" field.
 func (m *DMutation) ResetValue(field string) {
 	delete(m.data, field)
+	delete(m.added, field)
+	delete(m.clearedFields, field)
 }
 
 // AddedValue returns the value that was added to the "field" field in this mutation.
 func (m *DMutation) AddedValue(field string) (ent.Value, bool) {
-	if col, ok := m.table.Column(field); ok {
-		if col.Type.Integer() {
-			v, ok := m.data[field]
-			if !ok {
-				return nil, false
-			}
-			return v, true
-		}
-	}
+	v, ok := m.added[field]
+	return v, ok
+}
 
-	return nil, false
+// AddValue adds v to the numeric "field" field. The addition is executed by
+// the database on update (`SET field = field + v`), and values added multiple
+// times are summed. Values of non-numeric types or fields fail the mutation
+// with a ValidationError when it is saved.
+func (m *DMutation) AddValue(field string, v interface{}) {
+	if err := m.addValue(field, v); err != nil && m.err == nil {
+		m.err = &ValidationError{Name: field, err: fmt.Errorf("ent: %w", err)}
+	}
 }
 
-// AddValue returns the value that was added to the "field" field in this mutation.
-func (m *DMutation) AddValue(name string, i int) {
-	if col, ok := m.table.Column(name); ok {
-		if col.Type.Integer() {
-			v, ok := m.data[name]
-			if !ok {
-				m.data[name] = i
-			}
+// addValue adds v to the numeric field, and reports an error if the types mismatch.
+func (m *DMutation) addValue(field string, v interface{}) error {
+	col, ok := m.table.Column(field)
+	if !ok || !col.Type.Numeric() {
+		return fmt.Errorf("field %s is not numeric", field)
+	}
+	n, err := numericValue(col.Type, v)
+	if err != nil {
+		return fmt.Errorf("unexpected type %T for field %s", v, field)
+	}
+	if prev, ok := m.added[field]; ok {
+		switch prev := prev.(type) {
+		case int64:
+			n = prev + n.(int64)
+		case float64:
+			n = prev + n.(float64)
+		}
+	}
+	delete(m.data, field)
+	m.added[field] = n
+	return nil
+}
 
-			m.data[name] = v.(int) + i
+// numericValue converts v to the int64 or float64 value of the numeric type.
+func numericValue(typ field.Type, v interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(v)
+	var f float64
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if typ.Integer() {
+			return rv.Int(), nil
+		}
+		f = float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if typ.Integer() {
+			return int64(rv.Uint()), nil
 		}
+		f = float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		if typ.Integer() {
+			return nil, fmt.Errorf("unexpected float value for integer type")
+		}
+		f = rv.Float()
+	default:
+		return nil, fmt.Errorf("unexpected type %T", v)
 	}
+	return f, nil
 }
 
 // Where appends a list predicates to the DMutation builder.
@@ -284,12 +353,10 @@ func (m *DMutation) SetField(name string, value ent.Value) error {
 func (m *DMutation) AddedFields() []string {
 	var fields []string
 	for _, c := range m.table.Columns {
-		if c.Type.Integer() {
-
+		if _, ok := m.added[c.Name]; ok {
 			fields = append(fields, c.Name)
 		}
 	}
-
 	return fields
 }
 
@@ -304,14 +371,7 @@ func (m *DMutation) AddedField(name string) (ent.Value, bool) {
 // the field is not defined in the schema, or if the type mismatched the field
 // type.
 func (m *DMutation) AddField(name string, value ent.Value) error {
-
-	v, ok := value.(int)
-	if !ok {
-		return fmt.Errorf("unexpected type %T for field %s", value, name)
-	}
-
-	m.AddValue(name, v)
-	return nil
+	return m.addValue(name, value)
 }
 
 // ClearedFields returns all nullable fields that were cleared during this
@@ -395,3 +455,22 @@ func (m *DMutation) ClearEdge(name string) error {
 func (m *DMutation) ResetEdge(name string) error {
 	return fmt.Errorf("unknown Dynamic edge %s", name)
 }
+
+// check validates the field predicates of the mutation, and returns the
+// first error of the SQL expressions that are set on the mutation.
+func (m *DMutation) check() error {
+	if m.err != nil {
+		return m.err
+	}
+	if err := checkPredicates(m.table, m.table.Column, m.predicates...); err != nil {
+		return err
+	}
+	for _, v := range m.data {
+		if e, ok := v.(interface{ Err() error }); ok {
+			if err := e.Err(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
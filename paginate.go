@@ -0,0 +1,247 @@
+package dent
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/schema/field"
+)
+
+// Cursor is the position of a row in a paginated query. It holds the
+// values of the order fields of the row, and its ID as a tiebreaker.
+type Cursor struct {
+	ID     int           `json:"i"`
+	Values []interface{} `json:"v,omitempty"`
+}
+
+// String returns the opaque, URL-safe encoding of the cursor.
+func (c *Cursor) String() string {
+	b, err := json.Marshal(c)
+	if err != nil {
+		panic(fmt.Sprintf("ent: encoding cursor: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// ParseCursor decodes a cursor that was encoded by Cursor.String.
+func ParseCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("ent: invalid cursor: %w", err)
+	}
+	c := &Cursor{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(c); err != nil {
+		return nil, fmt.Errorf("ent: invalid cursor: %w", err)
+	}
+	return c, nil
+}
+
+// PageOrder is an order field of a paginated query.
+type PageOrder struct {
+	Field string
+	Desc  bool
+}
+
+// Page is a page of a paginated query.
+type Page struct {
+	Nodes           []*Dynamic
+	StartCursor     *Cursor
+	EndCursor       *Cursor
+	HasNextPage     bool
+	HasPreviousPage bool
+}
+
+// Paginate returns the first n rows of the query that come after the given cursor,
+// using keyset pagination on the given order fields. The ID field is always added as
+// the last order field, to keep the order stable for rows with equal values. A nil
+// cursor starts from the first row. The order, limit and offset steps of the query
+// are replaced by the pagination.
+//
+// Order fields must not be nullable, as NULL values can not be compared to the
+// cursor. HasPreviousPage is computed with an additional query if a cursor is given.
+//
+//	page, err := client.Table("user").Query().
+//		Where(IntGT("age", 18)).
+//		Paginate(ctx, after, 20, PageOrder{Field: "name"})
+func (dq *DQuery) Paginate(ctx context.Context, after *Cursor, first int, order ...PageOrder) (*Page, error) {
+	nodes, more, prev, err := dq.paginate(ctx, after, first, false, order)
+	if err != nil {
+		return nil, err
+	}
+	return newPage(nodes, order, more, prev), nil
+}
+
+// PaginateBackward is like Paginate, but returns the last n rows that come before
+// the given cursor. A nil cursor starts from the last row. HasNextPage is computed
+// with an additional query if a cursor is given.
+func (dq *DQuery) PaginateBackward(ctx context.Context, before *Cursor, last int, order ...PageOrder) (*Page, error) {
+	nodes, more, next, err := dq.paginate(ctx, before, last, true, order)
+	if err != nil {
+		return nil, err
+	}
+	// Rows were queried in reverse order.
+	for i, j := 0, len(nodes)-1; i < j; i, j = i+1, j-1 {
+		nodes[i], nodes[j] = nodes[j], nodes[i]
+	}
+	return newPage(nodes, order, next, more), nil
+}
+
+// paginate queries n rows from the cursor position, and reports if there are more rows
+// after them, and if there are rows on the other side of the cursor (or the cursor row).
+func (dq *DQuery) paginate(ctx context.Context, c *Cursor, n int, backward bool, order []PageOrder) ([]*Dynamic, bool, bool, error) {
+	if n <= 0 {
+		return nil, false, false, &ValidationError{Name: "first", err: fmt.Errorf("ent: invalid page size %d", n)}
+	}
+	types := make([]field.Type, len(order))
+	for i, o := range order {
+		col, ok := dq.table.Column(o.Field)
+		if !ok {
+			return nil, false, false, &ValidationError{Name: o.Field, err: fmt.Errorf("ent: invalid field %q for pagination", o.Field)}
+		}
+		if col.Nullable {
+			return nil, false, false, &ValidationError{Name: o.Field, err: fmt.Errorf("ent: nullable field %q can not be used for pagination", o.Field)}
+		}
+		types[i] = col.Type
+	}
+	query := dq.Clone()
+	query.order, query.offset = nil, nil
+	if len(query.fields) > 0 {
+		for _, o := range order {
+			query.fields = appendMissing(query.fields, o.Field)
+		}
+	}
+	var other *DQuery
+	if c != nil {
+		if len(c.Values) != len(order) {
+			return nil, false, false, &ValidationError{Name: "cursor", err: fmt.Errorf("ent: cursor has %d values, expected %d", len(c.Values), len(order))}
+		}
+		values := make([]interface{}, len(order))
+		for i, v := range c.Values {
+			var err error
+			if values[i], err = cursorValue(types[i], v); err != nil {
+				return nil, false, false, &ValidationError{Name: order[i].Field, err: fmt.Errorf("ent: invalid cursor value: %w", err)}
+			}
+			if values[i] == nil {
+				return nil, false, false, &ValidationError{Name: order[i].Field, err: fmt.Errorf("ent: invalid cursor value: null value for field %q", order[i].Field)}
+			}
+		}
+		other = query.Clone()
+		other.limit = nil
+		other.filter(keysetPredicate(order, values, c.ID, !backward, true))
+		query.filter(keysetPredicate(order, values, c.ID, backward, false))
+	}
+	query.Order(func(s *sql.Selector) {
+		for _, o := range order {
+			if o.Desc != backward {
+				s.OrderBy(sql.Desc(s.C(o.Field)))
+			} else {
+				s.OrderBy(sql.Asc(s.C(o.Field)))
+			}
+		}
+		if backward {
+			s.OrderBy(sql.Desc(s.C(FieldID)))
+		} else {
+			s.OrderBy(sql.Asc(s.C(FieldID)))
+		}
+	})
+	nodes, err := query.Limit(n + 1).All(ctx)
+	if err != nil {
+		return nil, false, false, err
+	}
+	more := len(nodes) > n
+	if more {
+		nodes = nodes[:n]
+	}
+	if other == nil {
+		return nodes, more, false, nil
+	}
+	exist, err := other.Exist(ctx)
+	if err != nil {
+		return nil, false, false, err
+	}
+	return nodes, more, exist, nil
+}
+
+// keysetPredicate returns the predicate for the rows that come after (or before)
+// the given values in the given order. For example, (a ASC, b DESC, id ASC):
+//
+//	a > ? OR (a = ? AND b < ?) OR (a = ? AND b = ? AND id > ?)
+//
+// If inclusive is true, the row of the cursor itself is also matched (id >= ?).
+func keysetPredicate(order []PageOrder, values []interface{}, id int, backward, inclusive bool) Predicate {
+	return func(s *sql.Selector) {
+		or := make([]*sql.Predicate, 0, len(order)+1)
+		cmp := func(column string, desc bool, v interface{}) *sql.Predicate {
+			if desc != backward {
+				return sql.LT(s.C(column), v)
+			}
+			return sql.GT(s.C(column), v)
+		}
+		for i := 0; i <= len(order); i++ {
+			and := make([]*sql.Predicate, 0, i+1)
+			for j := 0; j < i; j++ {
+				and = append(and, sql.EQ(s.C(order[j].Field), values[j]))
+			}
+			switch {
+			case i < len(order):
+				and = append(and, cmp(order[i].Field, order[i].Desc, values[i]))
+			case inclusive && backward:
+				and = append(and, sql.LTE(s.C(FieldID), id))
+			case inclusive:
+				and = append(and, sql.GTE(s.C(FieldID), id))
+			default:
+				and = append(and, cmp(FieldID, false, id))
+			}
+			or = append(or, sql.And(and...))
+		}
+		s.Where(sql.Or(or...))
+	}
+}
+
+// newPage returns the page of the given nodes.
+func newPage(nodes []*Dynamic, order []PageOrder, hasNext, hasPrev bool) *Page {
+	page := &Page{Nodes: nodes, HasNextPage: hasNext, HasPreviousPage: hasPrev}
+	if len(nodes) > 0 {
+		page.StartCursor = nodeCursor(nodes[0], order)
+		page.EndCursor = nodeCursor(nodes[len(nodes)-1], order)
+	}
+	return page
+}
+
+// nodeCursor returns the cursor of the given node.
+func nodeCursor(n *Dynamic, order []PageOrder) *Cursor {
+	c := &Cursor{ID: n.ID, Values: make([]interface{}, len(order))}
+	for i, o := range order {
+		c.Values[i] = n.Row[o.Field]
+	}
+	return c
+}
+
+// cursorValue converts a decoded cursor value to the Go type of its column.
+func cursorValue(typ field.Type, v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case json.Number:
+		return numberValue(typ, v)
+	case string:
+		if typ == field.TypeTime {
+			return parseTime(v)
+		}
+	}
+	return v, nil
+}
+
+// appendMissing appends s to the list if it is not already there.
+func appendMissing(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+	return append(list, s)
+}
@@ -0,0 +1,110 @@
+package dent
+
+import (
+	"context"
+	"testing"
+
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+)
+
+func TestPaginate(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("items")
+	table.AddColumn(&schema.Column{Name: "name", Type: field.TypeString})
+	table.AddColumn(&schema.Column{Name: "note", Type: field.TypeString, Nullable: true})
+	client := openTest(t, table)
+	// Ties are ordered by id.
+	for _, name := range []string{"b", "", "a", "b", "", "c", "a"} {
+		client.Table("items").Create().SetValue("name", name).SaveX(ctx)
+	}
+	tests := []struct {
+		order PageOrder
+		want  []int
+	}{
+		{order: PageOrder{Field: "name"}, want: []int{2, 5, 3, 7, 1, 4, 6}},
+		{order: PageOrder{Field: "name", Desc: true}, want: []int{6, 1, 4, 3, 7, 2, 5}},
+	}
+	for _, tt := range tests {
+		// Forward from the first row.
+		var (
+			ids   []int
+			after *Cursor
+		)
+		for i := 0; ; i++ {
+			page, err := client.Table("items").Query().Paginate(ctx, after, 2, tt.order)
+			if err != nil {
+				t.Fatalf("failed paginating: %v", err)
+			}
+			if page.HasPreviousPage != (i > 0) {
+				t.Errorf("%v: page %d: unexpected HasPreviousPage %v", tt.order, i, page.HasPreviousPage)
+			}
+			for _, n := range page.Nodes {
+				ids = append(ids, n.ID)
+			}
+			if !page.HasNextPage {
+				break
+			}
+			// Cursors are passed to clients as strings.
+			if after, err = ParseCursor(page.EndCursor.String()); err != nil {
+				t.Fatalf("failed parsing cursor: %v", err)
+			}
+		}
+		if !equalInts(ids, tt.want) {
+			t.Errorf("%v: paginated forward %v, want %v", tt.order, ids, tt.want)
+		}
+		// Backward from the last row.
+		var before *Cursor
+		ids = nil
+		for i := 0; ; i++ {
+			page, err := client.Table("items").Query().PaginateBackward(ctx, before, 3, tt.order)
+			if err != nil {
+				t.Fatalf("failed paginating backward: %v", err)
+			}
+			if page.HasNextPage != (i > 0) {
+				t.Errorf("%v: page %d: unexpected HasNextPage %v", tt.order, i, page.HasNextPage)
+			}
+			for j := len(page.Nodes) - 1; j >= 0; j-- {
+				ids = append(ids, page.Nodes[j].ID)
+			}
+			if !page.HasPreviousPage {
+				break
+			}
+			before = page.StartCursor
+		}
+		for i, j := 0, len(ids)-1; i < j; i, j = i+1, j-1 {
+			ids[i], ids[j] = ids[j], ids[i]
+		}
+		if !equalInts(ids, tt.want) {
+			t.Errorf("%v: paginated backward %v, want %v", tt.order, ids, tt.want)
+		}
+	}
+	// The row of the cursor is not required to exist.
+	page, err := client.Table("items").Query().Where(IntNEQ(FieldID, 2)).Paginate(ctx, &Cursor{ID: 2, Values: []interface{}{""}}, 1, PageOrder{Field: "name"})
+	if err != nil {
+		t.Fatalf("failed paginating: %v", err)
+	}
+	if len(page.Nodes) != 1 || page.Nodes[0].ID != 5 || page.HasPreviousPage || !page.HasNextPage {
+		t.Errorf("unexpected page after deleted row: %+v", page)
+	}
+	_, err = client.Table("items").Query().Paginate(ctx, nil, 1, PageOrder{Field: "note"})
+	if !IsValidationError(err) {
+		t.Errorf("expected validation error of nullable order field, got: %v", err)
+	}
+	_, err = client.Table("items").Query().Paginate(ctx, &Cursor{ID: 2, Values: []interface{}{nil}}, 1, PageOrder{Field: "name"})
+	if !IsValidationError(err) {
+		t.Errorf("expected validation error of null cursor value, got: %v", err)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
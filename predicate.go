@@ -1,8 +1,387 @@
 package dent
 
 import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"time"
+
 	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
 )
 
 // Predicate is the predicate function for dynamic builders.
 type Predicate func(*sql.Selector)
+
+// FieldPredicates builds the predicates of a field. Unlike the typed predicates
+// (e.g. IntEQ), they are validated against the schema of the field when the query
+// is prepared, and a ValidationError is returned if the field does not exist, or
+// if the values or the operation do not match its type.
+//
+//	client.Table("users").Query().
+//		Where(
+//			Field("age").Between(18, 30),
+//			Field("nickname").IsNull(),
+//			Field("updated_at").GTField("created_at"),
+//		).
+//		All(ctx)
+type FieldPredicates struct {
+	name string
+}
+
+// Field returns the predicate builder of the given field.
+func Field(name string) *FieldPredicates {
+	return &FieldPredicates{name: name}
+}
+
+// EQ applies the EQ predicate on the field.
+func (f *FieldPredicates) EQ(v interface{}) Predicate {
+	return f.predicate(f.checkValues(false, v), func(s *sql.Selector) *sql.Predicate {
+		return sql.EQ(s.C(f.name), v)
+	})
+}
+
+// NEQ applies the NEQ predicate on the field.
+func (f *FieldPredicates) NEQ(v interface{}) Predicate {
+	return f.predicate(f.checkValues(false, v), func(s *sql.Selector) *sql.Predicate {
+		return sql.NEQ(s.C(f.name), v)
+	})
+}
+
+// GT applies the GT predicate on the field.
+func (f *FieldPredicates) GT(v interface{}) Predicate {
+	return f.predicate(f.checkValues(true, v), func(s *sql.Selector) *sql.Predicate {
+		return sql.GT(s.C(f.name), v)
+	})
+}
+
+// GTE applies the GTE predicate on the field.
+func (f *FieldPredicates) GTE(v interface{}) Predicate {
+	return f.predicate(f.checkValues(true, v), func(s *sql.Selector) *sql.Predicate {
+		return sql.GTE(s.C(f.name), v)
+	})
+}
+
+// LT applies the LT predicate on the field.
+func (f *FieldPredicates) LT(v interface{}) Predicate {
+	return f.predicate(f.checkValues(true, v), func(s *sql.Selector) *sql.Predicate {
+		return sql.LT(s.C(f.name), v)
+	})
+}
+
+// LTE applies the LTE predicate on the field.
+func (f *FieldPredicates) LTE(v interface{}) Predicate {
+	return f.predicate(f.checkValues(true, v), func(s *sql.Selector) *sql.Predicate {
+		return sql.LTE(s.C(f.name), v)
+	})
+}
+
+// In applies the In predicate on the field.
+func (f *FieldPredicates) In(vs ...interface{}) Predicate {
+	return f.predicate(f.checkValues(false, vs...), func(s *sql.Selector) *sql.Predicate {
+		return sql.In(s.C(f.name), vs...)
+	})
+}
+
+// NotIn applies the NotIn predicate on the field.
+func (f *FieldPredicates) NotIn(vs ...interface{}) Predicate {
+	return f.predicate(f.checkValues(false, vs...), func(s *sql.Selector) *sql.Predicate {
+		return sql.NotIn(s.C(f.name), vs...)
+	})
+}
+
+// Between applies the predicate that checks that the field is between
+// the given values, inclusive.
+func (f *FieldPredicates) Between(from, to interface{}) Predicate {
+	return f.predicate(f.checkValues(true, from, to), func(s *sql.Selector) *sql.Predicate {
+		return sql.P(func(b *sql.Builder) {
+			b.Ident(s.C(f.name)).WriteString(" BETWEEN ").Arg(from).WriteString(" AND ").Arg(to)
+		})
+	})
+}
+
+// IsNull applies the IsNull predicate on the field.
+func (f *FieldPredicates) IsNull() Predicate {
+	return f.predicate(nil, func(s *sql.Selector) *sql.Predicate {
+		return sql.IsNull(s.C(f.name))
+	})
+}
+
+// NotNull applies the NotNull predicate on the field.
+func (f *FieldPredicates) NotNull() Predicate {
+	return f.predicate(nil, func(s *sql.Selector) *sql.Predicate {
+		return sql.NotNull(s.C(f.name))
+	})
+}
+
+// Like applies the LIKE predicate on the string field. The "%" and "_" wildcards
+// of the pattern can be matched literally by escaping them with a backslash.
+func (f *FieldPredicates) Like(pattern string) Predicate {
+	return f.predicate(f.checkString, func(s *sql.Selector) *sql.Predicate {
+		return sql.P(func(b *sql.Builder) {
+			b.Ident(s.C(f.name)).WriteString(" LIKE ").Arg(pattern).WriteString(" ESCAPE ").Arg("\\")
+		})
+	})
+}
+
+// Contains applies the Contains predicate on the string field.
+func (f *FieldPredicates) Contains(v string) Predicate {
+	return f.predicate(f.checkString, func(s *sql.Selector) *sql.Predicate {
+		return sql.Contains(s.C(f.name), v)
+	})
+}
+
+// HasPrefix applies the HasPrefix predicate on the string field.
+func (f *FieldPredicates) HasPrefix(v string) Predicate {
+	return f.predicate(f.checkString, func(s *sql.Selector) *sql.Predicate {
+		return sql.HasPrefix(s.C(f.name), v)
+	})
+}
+
+// HasSuffix applies the HasSuffix predicate on the string field.
+func (f *FieldPredicates) HasSuffix(v string) Predicate {
+	return f.predicate(f.checkString, func(s *sql.Selector) *sql.Predicate {
+		return sql.HasSuffix(s.C(f.name), v)
+	})
+}
+
+// EqualFold applies the EqualFold predicate on the string field.
+func (f *FieldPredicates) EqualFold(v string) Predicate {
+	return f.predicate(f.checkString, func(s *sql.Selector) *sql.Predicate {
+		return sql.EqualFold(s.C(f.name), v)
+	})
+}
+
+// ContainsFold applies the ContainsFold predicate on the string field.
+func (f *FieldPredicates) ContainsFold(v string) Predicate {
+	return f.predicate(f.checkString, func(s *sql.Selector) *sql.Predicate {
+		return sql.ContainsFold(s.C(f.name), v)
+	})
+}
+
+// EQField applies the EQ predicate between the field and the other field.
+func (f *FieldPredicates) EQField(other string) Predicate {
+	return f.columnsOp(other, sql.OpEQ)
+}
+
+// NEQField applies the NEQ predicate between the field and the other field.
+func (f *FieldPredicates) NEQField(other string) Predicate {
+	return f.columnsOp(other, sql.OpNEQ)
+}
+
+// GTField applies the GT predicate between the field and the other field.
+func (f *FieldPredicates) GTField(other string) Predicate {
+	return f.columnsOp(other, sql.OpGT)
+}
+
+// GTEField applies the GTE predicate between the field and the other field.
+func (f *FieldPredicates) GTEField(other string) Predicate {
+	return f.columnsOp(other, sql.OpGTE)
+}
+
+// LTField applies the LT predicate between the field and the other field.
+func (f *FieldPredicates) LTField(other string) Predicate {
+	return f.columnsOp(other, sql.OpLT)
+}
+
+// LTEField applies the LTE predicate between the field and the other field.
+func (f *FieldPredicates) LTEField(other string) Predicate {
+	return f.columnsOp(other, sql.OpLTE)
+}
+
+// columnsOp applies the comparison operator between the field and the other field.
+func (f *FieldPredicates) columnsOp(other string, op sql.Op) Predicate {
+	ordered := op != sql.OpEQ && op != sql.OpNEQ
+	check := func(c *schema.Column, column columnFunc) error {
+		o, ok := column(other)
+		if !ok {
+			return fmt.Errorf("ent: invalid field %q for predicate", other)
+		}
+		if ordered && !isOrdered(c.Type) {
+			return fmt.Errorf("ent: field %q of type %s is not ordered", f.name, c.Type)
+		}
+		if !isComparable(c.Type, o.Type) {
+			return fmt.Errorf("ent: cannot compare field %q of type %s with field %q of type %s", f.name, c.Type, other, o.Type)
+		}
+		return nil
+	}
+	return f.predicate(check, func(s *sql.Selector) *sql.Predicate {
+		return sql.ColumnsOp(s.C(f.name), s.C(other), op)
+	})
+}
+
+// predicate returns the predicate built by fn, that is validated with check
+// when the predicates of the query are checked (see checkPredicates).
+func (f *FieldPredicates) predicate(check func(*schema.Column, columnFunc) error, fn func(*sql.Selector) *sql.Predicate) Predicate {
+	return Predicate(func(s *sql.Selector) {
+		if pc, ok := s.Context().Value(predicateCheckKey{}).(*predicateCheck); ok && pc.err == nil {
+			c, ok := pc.column(f.name)
+			var err error
+			switch {
+			case !ok:
+				err = fmt.Errorf("ent: invalid field %q for predicate", f.name)
+			case check != nil:
+				err = check(c, pc.column)
+			}
+			if err != nil {
+				pc.err = &ValidationError{Name: f.name, err: err}
+			}
+		}
+		s.Where(fn(s))
+	})
+}
+
+// checkValues returns the check of the values of a predicate, where ordered
+// reports if the predicate applies an ordering on the field (e.g. GT).
+func (f *FieldPredicates) checkValues(ordered bool, vs ...interface{}) func(*schema.Column, columnFunc) error {
+	return func(c *schema.Column, _ columnFunc) error {
+		if ordered && !isOrdered(c.Type) {
+			return fmt.Errorf("ent: field %q of type %s is not ordered", f.name, c.Type)
+		}
+		for _, v := range vs {
+			if err := checkValue(f.name, c, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// checkString checks that the field is a string field.
+func (f *FieldPredicates) checkString(c *schema.Column, _ columnFunc) error {
+	if c.Type != field.TypeString && c.Type != field.TypeEnum {
+		return fmt.Errorf("ent: field %q of type %s is not a string field", f.name, c.Type)
+	}
+	return nil
+}
+
+// columnFunc returns the schema of a column by its name.
+type columnFunc func(string) (*schema.Column, bool)
+
+// predicateCheck holds the first error of the field predicates that are checked.
+type predicateCheck struct {
+	column columnFunc
+	err    error
+}
+
+// predicateCheckKey is the context key of the predicate check of a selector.
+type predicateCheckKey struct{}
+
+// checkPredicates validates the field predicates against the columns of the table,
+// by applying them on a selector that is not executed.
+func checkPredicates(t *Table, column columnFunc, ps ...Predicate) error {
+	pc := &predicateCheck{column: column}
+	ctx := context.WithValue(context.Background(), predicateCheckKey{}, pc)
+	s := sql.Dialect(t.driver.Dialect()).Select().From(sql.Table(t.Name)).WithContext(ctx)
+	for _, p := range ps {
+		p(s)
+	}
+	return pc.err
+}
+
+// addError adds the error of a predicate to the selector. If the predicates are checked,
+// it is kept by the check instead, as the errors of selectors are joined to plain errors.
+func addError(s *sql.Selector, err error) {
+	if pc, ok := s.Context().Value(predicateCheckKey{}).(*predicateCheck); ok {
+		if pc.err == nil {
+			pc.err = err
+		}
+		return
+	}
+	s.AddError(err)
+}
+
+var (
+	timeType  = reflect.TypeOf(time.Time{})
+	bytesType = reflect.TypeOf([]byte(nil))
+)
+
+// checkValue checks that the value can be compared with the column of the field.
+func checkValue(name string, c *schema.Column, v interface{}) error {
+	if v == nil {
+		return fmt.Errorf("ent: nil value for field %q, use IsNull instead", name)
+	}
+	if _, ok := v.(driver.Valuer); ok {
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return fmt.Errorf("ent: nil value for field %q, use IsNull instead", name)
+		}
+		rv = rv.Elem()
+	}
+	var ok bool
+	switch k := rv.Kind(); typeClass(c.Type) {
+	case "bool":
+		ok = k == reflect.Bool
+	case "int":
+		ok = isInt(k)
+	case "float":
+		ok = isInt(k) || k == reflect.Float32 || k == reflect.Float64
+	case "string":
+		ok = k == reflect.String
+	case "time":
+		ok = rv.Type() == timeType
+	case "bytes":
+		ok = rv.Type() == bytesType || k == reflect.String
+	default:
+		ok = true
+	}
+	if !ok {
+		return fmt.Errorf("ent: invalid value of type %T for field %q of type %s", v, name, c.Type)
+	}
+	return nil
+}
+
+// isInt reports if the kind is an integer kind.
+func isInt(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Uint64
+}
+
+// isComparable reports if the values of the types can be compared with each other.
+func isComparable(t1, t2 field.Type) bool {
+	c1, c2 := typeClass(t1), typeClass(t2)
+	switch {
+	case c1 == c2, c1 == "other", c2 == "other":
+		return true
+	default:
+		return (c1 == "int" || c1 == "float") && (c2 == "int" || c2 == "float")
+	}
+}
+
+// isOrdered reports if the values of the type can be ordered.
+func isOrdered(t field.Type) bool {
+	switch typeClass(t) {
+	case "bool", "json":
+		return false
+	default:
+		return true
+	}
+}
+
+// typeClass returns the class of the values of the type, where
+// types of the same class can be compared with each other.
+func typeClass(t field.Type) string {
+	switch t {
+	case field.TypeBool:
+		return "bool"
+	case field.TypeInt, field.TypeInt8, field.TypeInt16, field.TypeInt32, field.TypeInt64,
+		field.TypeUint, field.TypeUint8, field.TypeUint16, field.TypeUint32, field.TypeUint64:
+		return "int"
+	case field.TypeFloat32, field.TypeFloat64:
+		return "float"
+	case field.TypeString, field.TypeEnum:
+		return "string"
+	case field.TypeTime:
+		return "time"
+	case field.TypeBytes:
+		return "bytes"
+	case field.TypeJSON:
+		return "json"
+	default:
+		return "other"
+	}
+}
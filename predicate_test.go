@@ -0,0 +1,141 @@
+package dent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+)
+
+func TestFieldPredicates(t *testing.T) {
+	ctx := context.Background()
+	users := NewTable("users")
+	users.AddColumn(&schema.Column{Name: "name", Type: field.TypeString})
+	users.AddColumn(&schema.Column{Name: "age", Type: field.TypeInt})
+	users.AddColumn(&schema.Column{Name: "score", Type: field.TypeFloat64})
+	users.AddColumn(&schema.Column{Name: "nickname", Type: field.TypeString, Nullable: true})
+	users.AddColumn(&schema.Column{Name: "active", Type: field.TypeBool})
+	users.AddColumn(&schema.Column{Name: "created_at", Type: field.TypeTime})
+	users.AddColumn(&schema.Column{Name: "updated_at", Type: field.TypeTime})
+	client := openTest(t, users)
+	var (
+		queries []string
+		at      = time.Date(2022, 8, 1, 0, 0, 0, 0, time.UTC)
+	)
+	for i, name := range []string{"a%b", "a_c", "abd", "x"} {
+		c := client.Table("users").Create().
+			SetValue("name", name).
+			SetValue("age", 10*(i+1)).
+			SetValue("score", float64(i)+0.5).
+			SetValue("active", i%2 == 0).
+			SetValue("created_at", at).
+			SetValue("updated_at", at.Add(time.Duration(i)*time.Hour))
+		if i%2 == 0 {
+			c.SetValue("nickname", fmt.Sprint("n", i))
+		}
+		c.SaveX(ctx)
+	}
+	client.log = func(v ...interface{}) {
+		queries = append(queries, fmt.Sprint(v...))
+	}
+	client = client.Debug()
+
+	for _, tt := range []struct {
+		name string
+		p    Predicate
+		want []string
+	}{
+		{name: "between", p: Field("age").Between(20, 30), want: []string{"a_c", "abd"}},
+		{name: "between floats", p: Field("score").Between(1, 2.5), want: []string{"a_c", "abd"}},
+		{name: "is null", p: Field("nickname").IsNull(), want: []string{"a_c", "x"}},
+		{name: "not null", p: Field("nickname").NotNull(), want: []string{"a%b", "abd"}},
+		{name: "like", p: Field("name").Like("a%"), want: []string{"a%b", "a_c", "abd"}},
+		{name: "like escaped percent", p: Field("name").Like(`a\%%`), want: []string{"a%b"}},
+		{name: "like escaped underscore", p: Field("name").Like(`a\_c`), want: []string{"a_c"}},
+		{name: "eq field", p: Field("updated_at").EQField("created_at"), want: []string{"a%b"}},
+		{name: "gt field", p: Field("updated_at").GTField("created_at"), want: []string{"a_c", "abd", "x"}},
+		{name: "gt field of numbers", p: Field("score").GTField("age"), want: nil},
+		{name: "in", p: Field("age").In(10, 40), want: []string{"a%b", "x"}},
+		{name: "bool", p: Field("active").EQ(true), want: []string{"a%b", "abd"}},
+	} {
+		queries = nil
+		if got := names(t, client.Table("users").Query().Where(tt.p).Order(Asc(FieldID))); !equalStrings(got, tt.want) {
+			t.Errorf("%s: unexpected rows: %v", tt.name, got)
+		}
+		if strings.HasPrefix(tt.name, "like") && (len(queries) != 1 || !strings.Contains(queries[0], "LIKE ? ESCAPE ?")) {
+			t.Errorf("%s: expected escaped LIKE in queries: %v", tt.name, queries)
+		}
+	}
+
+	for _, tt := range []struct {
+		name string
+		p    Predicate
+		want string
+	}{
+		{name: "unknown field", p: Field("nope").EQ(1), want: `invalid field "nope"`},
+		{name: "int with string", p: Field("age").EQ("10"), want: `invalid value of type string for field "age"`},
+		{name: "int with float", p: Field("age").Between(1, 2.5), want: `invalid value of type float64 for field "age"`},
+		{name: "string with int", p: Field("name").In("a", 1), want: `invalid value of type int for field "name"`},
+		{name: "time with string", p: Field("created_at").GT("2022-08-01"), want: `invalid value of type string for field "created_at"`},
+		{name: "nil value", p: Field("nickname").EQ(nil), want: "use IsNull instead"},
+		{name: "nil pointer", p: Field("age").EQ((*int)(nil)), want: "use IsNull instead"},
+		{name: "ordered bool", p: Field("active").GT(false), want: "is not ordered"},
+		{name: "like of int", p: Field("age").Like("1%"), want: "is not a string field"},
+		{name: "unknown other field", p: Field("age").EQField("nope"), want: `invalid field "nope"`},
+		{name: "incomparable fields", p: Field("age").EQField("name"), want: "cannot compare field"},
+		{name: "ordered bool fields", p: Field("active").LTField("active"), want: "is not ordered"},
+	} {
+		queries = nil
+		_, err := client.Table("users").Query().Where(tt.p).All(ctx)
+		if !IsValidationError(err) || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: expected validation error %q, got: %v", tt.name, tt.want, err)
+		}
+		if len(queries) > 0 {
+			t.Errorf("%s: unexpected queries: %v", tt.name, queries)
+		}
+	}
+	// Values of pointers are checked by their elements.
+	age := 10
+	if got := names(t, client.Table("users").Query().Where(Field("age").EQ(&age))); !equalStrings(got, []string{"a%b"}) {
+		t.Errorf("unexpected rows of pointer value: %v", got)
+	}
+}
+
+func TestFieldPredicatesSubquery(t *testing.T) {
+	ctx := context.Background()
+	users := NewTable("users")
+	users.AddColumn(&schema.Column{Name: "name", Type: field.TypeString})
+	posts := NewTable("posts")
+	posts.AddColumn(&schema.Column{Name: "user_id", Type: field.TypeInt})
+	posts.AddColumn(&schema.Column{Name: "likes", Type: field.TypeInt})
+	client := openTest(t, users, posts)
+	for _, name := range []string{"a", "b"} {
+		client.Table("users").Create().SetValue("name", name).SaveX(ctx)
+	}
+	for _, p := range [][2]int{{1, 10}, {2, 1}} {
+		client.Table("posts").Create().SetValue("user_id", p[0]).SetValue("likes", p[1]).SaveX(ctx)
+	}
+	// The predicates of subqueries are checked against their own tables.
+	popular := client.Table("posts").Query().Where(Field("likes").GT(5))
+	for _, q := range []*DQuery{
+		client.Table("users").Query().Where(InQuery(FieldID, popular.Clone().Select("user_id"))),
+		client.Table("users").Query().Where(ExistsQuery(popular.Clone(), On(FieldID, "user_id"))),
+		client.Table("users").Query().Where(Field("name").EQ("a"), NotExistsQuery(client.Table("posts").Query().Where(Field("likes").LT(5)), On(FieldID, "user_id"))),
+	} {
+		if got := names(t, q); !equalStrings(got, []string{"a"}) {
+			t.Errorf("unexpected users: %v", got)
+		}
+	}
+	for _, q := range []*DQuery{
+		client.Table("users").Query().Where(InQuery(FieldID, client.Table("posts").Query().Where(Field("name").EQ("a")).Select("user_id"))),
+		client.Table("users").Query().Where(ExistsQuery(client.Table("posts").Query().Where(Field("likes").EQ("a")), On(FieldID, "user_id"))),
+	} {
+		if _, err := q.All(ctx); !IsValidationError(err) {
+			t.Errorf("expected validation error of subquery predicate, got: %v", err)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package dent
 
 import (
 	"context"
-	"database/sql/driver"
 	"fmt"
 	"math"
 
@@ -12,10 +11,13 @@ import (
 	"entgo.io/ent/schema/field"
 )
 
+// WithQuery is the eager-loading configuration of a relation of a query.
 type WithQuery struct {
-	fromkey string
-	single  bool
-	query   *DQuery
+	relation *Relation
+	query    *DQuery
+	// err of an unknown relation, that
+	// is returned when the query is run.
+	err error
 }
 
 // DQuery is the builder for querying Dynamic entities.
@@ -27,11 +29,17 @@ type DQuery struct {
 	fields []string
 
 	predicates []Predicate
+	joins      []*join
+	windows    []*Window
+	qualify    []Predicate
 	// intermediate query (i.e. traversal path).
 	sql  *sql.Selector
 	path func(context.Context) (*sql.Selector, error)
 	// eager-loading edges.
 	withData map[string]*WithQuery
+	tree     *treeQuery
+	rollups  []*rollup
+	lock     *rowLock
 	// build
 	table *Table
 }
@@ -244,10 +252,11 @@ func (dq *DQuery) Clone() *DQuery {
 	}
 
 	withData := make(map[string]*WithQuery)
-	for k, dq2 := range dq.withData {
+	for k, wq := range dq.withData {
 		withData[k] = &WithQuery{
-			fromkey: dq2.fromkey,
-			query:   dq2.query.Clone(),
+			relation: wq.relation,
+			query:    wq.query.Clone(),
+			err:      wq.err,
 		}
 	}
 
@@ -255,10 +264,17 @@ func (dq *DQuery) Clone() *DQuery {
 		limit:      dq.limit,
 		offset:     dq.offset,
 		order:      append([]OrderFunc{}, dq.order...),
+		fields:     append([]string{}, dq.fields...),
 		predicates: append([]Predicate{}, dq.predicates...),
+		joins:      append([]*join{}, dq.joins...),
+		windows:    append([]*Window{}, dq.windows...),
+		qualify:    append([]Predicate{}, dq.qualify...),
 		// clone intermediate query.
 		sql:      dq.sql.Clone(),
 		withData: withData,
+		tree:     dq.tree,
+		rollups:  append([]*rollup{}, dq.rollups...),
+		lock:     dq.lock,
 		path:     dq.path,
 		unique:   dq.unique,
 		table:    dq.table,
@@ -269,14 +285,13 @@ func (dq *DQuery) Clone() *DQuery {
 // 通过当前数据的fromKey字段对应的ID的去查询对应的关联表中的值
 // 如查询用户的创建者
 func (dq *DQuery) WithData(table, storeKey, fromKey string, opts ...func(*DQuery)) *DQuery {
-	query := &DQuery{table: dq.table.client.Table(table), withData: make(map[string]*WithQuery)}
+	query := dq.table.client.Table(table).Query()
 	for _, opt := range opts {
 		opt(query)
 	}
 	dq.withData[storeKey] = &WithQuery{
-		fromkey: fromKey,
-		single:  true,
-		query:   query,
+		relation: BelongsTo(storeKey, table, fromKey),
+		query:    query,
 	}
 	return dq
 }
@@ -285,14 +300,13 @@ func (dq *DQuery) WithData(table, storeKey, fromKey string, opts ...func(*DQuery
 // 通过当前数据的ID去查询fromKey字段对应的ID的所有列表值
 // 如查询用户的角色列表
 func (dq *DQuery) WithListData(table, storeKey, fromKey string, opts ...func(*DQuery)) *DQuery {
-	query := &DQuery{table: dq.table.client.Table(table), withData: make(map[string]*WithQuery)}
+	query := dq.table.client.Table(table).Query()
 	for _, opt := range opts {
 		opt(query)
 	}
 	dq.withData[storeKey] = &WithQuery{
-		fromkey: fromKey,
-		single:  false,
-		query:   query,
+		relation: HasMany(storeKey, table, fromKey),
+		query:    query,
 	}
 	return dq
 }
@@ -312,8 +326,25 @@ func (dq *DQuery) WithListData(table, storeKey, fromKey string, opts ...func(*DQ
 //		Aggregate(ent.Count()).
 //		Scan(ctx, &v)
 func (dq *DQuery) GroupBy(field string, fields ...string) *DynamicGroupBy {
+	return dq.groupBy(append([]string{field}, fields...))
+}
+
+// Aggregate returns a builder that applies the given aggregation functions on
+// all rows of the query, without grouping them.
+//
+// Example:
+//
+//	count, err := client.Table("users").Query().
+//		Where(IntGT("age", 30)).
+//		Aggregate(CountDistinct("name")).
+//		Int(ctx)
+func (dq *DQuery) Aggregate(fns ...AggregateFunc) *DynamicGroupBy {
+	return dq.groupBy(nil).Aggregate(fns...)
+}
+
+func (dq *DQuery) groupBy(fields []string) *DynamicGroupBy {
 	grbuild := &DynamicGroupBy{table: dq.table}
-	grbuild.fields = append([]string{field}, fields...)
+	grbuild.fields = fields
 	grbuild.path = func(ctx context.Context) (prev *sql.Selector, err error) {
 		if err := dq.prepareQuery(ctx); err != nil {
 			return nil, err
@@ -347,17 +378,39 @@ func (dq *DQuery) Select(fields ...string) *DynamicSelect {
 
 func (dq *DQuery) prepareQuery(ctx context.Context) error {
 	for _, f := range dq.fields {
-		if !dq.table.HasColumn(f) {
+		if !dq.hasColumn(f) {
 			return &ValidationError{Name: f, err: fmt.Errorf("ent: invalid field %q for query", f)}
 		}
 	}
+	if err := dq.checkLock(); err != nil {
+		return err
+	}
+	ps := append(dq.predicates[:len(dq.predicates):len(dq.predicates)], dq.qualify...)
+	if err := checkPredicates(dq.table, dq.column, ps...); err != nil {
+		return err
+	}
+	var prev *sql.Selector
 	if dq.path != nil {
-		prev, err := dq.path(ctx)
-		if err != nil {
+		var err error
+		if prev, err = dq.path(ctx); err != nil {
 			return err
 		}
 		dq.sql = prev
 	}
+	if len(dq.joins) > 0 {
+		joined, err := dq.joinQuery(ctx, prev)
+		if err != nil {
+			return err
+		}
+		dq.sql, prev = joined, joined
+	}
+	if len(dq.windows) > 0 {
+		windowed, err := dq.windowQuery(ctx, prev)
+		if err != nil {
+			return err
+		}
+		dq.sql = windowed
+	}
 	return nil
 }
 
@@ -367,7 +420,7 @@ func (dq *DQuery) sqlAll(ctx context.Context, hooks ...queryHook) ([]*Dynamic, e
 		_spec = dq.querySpec()
 	)
 	_spec.ScanValues = func(columns []string) ([]interface{}, error) {
-		return dq.table.scanValues(columns)
+		return dq.scanValues(columns)
 	}
 	_spec.Assign = func(columns []string, values []interface{}) error {
 		node := &Dynamic{table: dq.table, Row: make(map[string]ent.Value)}
@@ -386,64 +439,73 @@ func (dq *DQuery) sqlAll(ctx context.Context, hooks ...queryHook) ([]*Dynamic, e
 		return nodes, nil
 	}
 
-	for key, dq2 := range dq.withData {
-		if dq2.single {
-			ids := make([]int, 0, len(nodes))
-			nodeids := make(map[int][]*Dynamic)
-			for i := range nodes {
-				fk := int(nodes[i].Row[dq2.fromkey].(int64))
-				if _, ok := nodeids[fk]; !ok {
-					ids = append(ids, fk)
-				}
-				nodeids[fk] = append(nodeids[fk], nodes[i])
-			}
-			query := dq2.query
-			query.Where(IDIn(ids...))
-			neighbors, err := query.All(ctx)
-			if err != nil {
-				return nil, err
-			}
-			for _, n := range neighbors {
-				nodes, ok := nodeids[n.ID]
-				if !ok {
-					return nil, fmt.Errorf(`unexpected foreign-key "editor_id" returned %v`, n.ID)
-				}
-				for i := range nodes {
-					nodes[i].Edges.SingleMap[key] = n
-				}
-			}
-		} else {
-			fks := make([]driver.Value, 0, len(nodes))
-			nodeids := make(map[int]*Dynamic)
-			for i := range nodes {
-				fks = append(fks, nodes[i].ID)
-				nodeids[nodes[i].ID] = nodes[i]
-			}
-			query := dq2.query
-			query.Where(Predicate(func(s *sql.Selector) {
-				s.Where(sql.InValues(dq2.fromkey, fks...))
-			}))
-			neighbors, err := query.All(ctx)
-			if err != nil {
-				return nil, err
-			}
-			for _, n := range neighbors {
-				fk := int(n.Row[dq2.fromkey].(int64))
-				node, ok := nodeids[fk]
-				if !ok {
-					return nil, fmt.Errorf(`unexpected foreign-key "d_id" returned %v for node %v`, fk, n.ID)
-				}
-				node.Edges.ListMap[key] = append(node.Edges.ListMap[key], n)
-			}
+	if err := dq.loadEdges(ctx, nodes); err != nil {
+		return nil, err
+	}
+	if err := dq.loadRollups(ctx, nodes); err != nil {
+		return nil, err
+	}
+	if dq.tree != nil {
+		if err := dq.loadTree(ctx, nodes); err != nil {
+			return nil, err
 		}
 	}
 
 	return nodes, nil
 }
 
+// sqlEach executes the query and calls fn with the scanned values of each row,
+// while the rows are read from the database. If header is not nil, it is called
+// with the columns of the result before its rows, even if there are no rows.
+func (dq *DQuery) sqlEach(ctx context.Context, header func(columns []string) error, fn func(columns []string, values []interface{}) error) error {
+	rows, columns, err := dq.sqlRows(ctx, dq.sqlQuery(ctx))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if header != nil {
+		if err := header(columns); err != nil {
+			return err
+		}
+	}
+	for rows.Next() {
+		values, err := dq.scanValues(columns)
+		if err != nil {
+			return err
+		}
+		if err := rows.Scan(values...); err != nil {
+			return err
+		}
+		if err := fn(columns, values); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// sqlRows executes the given selector and returns its open rows and columns.
+func (dq *DQuery) sqlRows(ctx context.Context, selector *sql.Selector) (*sql.Rows, []string, error) {
+	if err := selector.Err(); err != nil {
+		return nil, nil, err
+	}
+	rows := &sql.Rows{}
+	query, args := selector.Query()
+	if err := dq.table.driver.Query(ctx, query, args, rows); err != nil {
+		return nil, nil, err
+	}
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		return nil, nil, err
+	}
+	return rows, columns, nil
+}
+
 func (dq *DQuery) sqlCount(ctx context.Context) (int, error) {
 	_spec := dq.querySpec()
 	_spec.Node.Columns = dq.fields
+	// Rows are not locked by counts.
+	_spec.Modifiers = nil
 	if len(dq.fields) > 0 {
 		_spec.Unique = dq.unique != nil && *dq.unique
 	}
@@ -462,14 +524,18 @@ func (dq *DQuery) querySpec() *sqlgraph.QuerySpec {
 	_spec := &sqlgraph.QuerySpec{
 		Node: &sqlgraph.NodeSpec{
 			Table:   dq.table.Name,
-			Columns: dq.table.GetColumns(),
+			Columns: dq.columns(),
 			ID: &sqlgraph.FieldSpec{
 				Type:   field.TypeInt,
 				Column: FieldID,
 			},
 		},
-		From:   dq.sql,
-		Unique: true,
+		From: dq.sql,
+		// DISTINCT is not allowed with locks in PostgreSQL.
+		Unique: dq.lock == nil,
+	}
+	if dq.lock != nil {
+		_spec.Modifiers = append(_spec.Modifiers, dq.lockRows)
 	}
 	if unique := dq.unique; unique != nil {
 		_spec.Unique = *unique
@@ -483,7 +549,7 @@ func (dq *DQuery) querySpec() *sqlgraph.QuerySpec {
 			}
 		}
 	}
-	if ps := dq.predicates; len(ps) > 0 {
+	if ps := dq.outerPredicates(); len(ps) > 0 {
 		_spec.Predicate = func(selector *sql.Selector) {
 			for i := range ps {
 				ps[i](selector)
@@ -511,9 +577,9 @@ func (dq *DQuery) sqlQuery(ctx context.Context) *sql.Selector {
 	t1 := builder.Table(dq.table.Name)
 	columns := dq.fields
 	if len(columns) == 0 {
-		columns = dq.table.GetColumns()
+		columns = dq.columns()
 	}
-	selector := builder.Select(t1.Columns(columns...)...).From(t1)
+	selector := builder.Select(t1.Columns(columns...)...).From(t1).WithContext(ctx)
 	if dq.sql != nil {
 		selector = dq.sql
 		selector.Select(selector.Columns(columns...)...)
@@ -521,7 +587,7 @@ func (dq *DQuery) sqlQuery(ctx context.Context) *sql.Selector {
 	if dq.unique != nil && *dq.unique {
 		selector.Distinct()
 	}
-	for _, p := range dq.predicates {
+	for _, p := range dq.outerPredicates() {
 		p(selector)
 	}
 	for _, p := range dq.order {
@@ -535,6 +601,7 @@ func (dq *DQuery) sqlQuery(ctx context.Context) *sql.Selector {
 	if limit := dq.limit; limit != nil {
 		selector.Limit(*limit)
 	}
+	dq.lockRows(selector)
 	return selector
 }
 
@@ -543,6 +610,7 @@ type DynamicGroupBy struct {
 	selector
 	fields []string
 	fns    []AggregateFunc
+	having []Predicate
 	// intermediate query (i.e. traversal path).
 	sql   *sql.Selector
 	path  func(context.Context) (*sql.Selector, error)
@@ -555,6 +623,74 @@ func (dgb *DynamicGroupBy) Aggregate(fns ...AggregateFunc) *DynamicGroupBy {
 	return dgb
 }
 
+// Having adds the given predicates to the HAVING clause of the group-by query.
+// Aggregated values are filtered with the Aggregate predicates, for example:
+//
+//	client.Table("posts").Query().
+//		GroupBy("user_id").
+//		Aggregate(Count()).
+//		Having(AggregateGTE(Count(), 10)).
+//		Maps(ctx)
+func (dgb *DynamicGroupBy) Having(ps ...Predicate) *DynamicGroupBy {
+	dgb.having = append(dgb.having, ps...)
+	return dgb
+}
+
+// Dynamics applies the group-by query and returns its rows as Dynamic entities.
+// Grouped fields are scanned with the types of the table columns, and aggregated
+// values with the column types that are reported by the driver.
+func (dgb *DynamicGroupBy) Dynamics(ctx context.Context) ([]*Dynamic, error) {
+	query, err := dgb.path(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dgb.sql = query
+	rows, err := dgb.sqlRows(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return dgb.table.scanRows(rows)
+}
+
+// DynamicsX is like Dynamics, but panics if an error occurs.
+func (dgb *DynamicGroupBy) DynamicsX(ctx context.Context) []*Dynamic {
+	v, err := dgb.Dynamics(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// Maps applies the group-by query and returns its rows as maps from the
+// selected column names (or the aliases given with As) to their values.
+func (dgb *DynamicGroupBy) Maps(ctx context.Context) ([]map[string]interface{}, error) {
+	nodes, err := dgb.Dynamics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	v := make([]map[string]interface{}, len(nodes))
+	for i, node := range nodes {
+		v[i] = make(map[string]interface{}, len(node.Row)+1)
+		for column, value := range node.Row {
+			v[i][column] = value
+		}
+		if node.ID != 0 {
+			v[i][FieldID] = node.ID
+		}
+	}
+	return v, nil
+}
+
+// MapsX is like Maps, but panics if an error occurs.
+func (dgb *DynamicGroupBy) MapsX(ctx context.Context) []map[string]interface{} {
+	v, err := dgb.Maps(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
 // Scan applies the group-by query and scans the result into the given value.
 func (dgb *DynamicGroupBy) Scan(ctx context.Context, v interface{}) error {
 	query, err := dgb.path(ctx)
@@ -566,22 +702,30 @@ func (dgb *DynamicGroupBy) Scan(ctx context.Context, v interface{}) error {
 }
 
 func (dgb *DynamicGroupBy) sqlScan(ctx context.Context, v interface{}) error {
+	rows, err := dgb.sqlRows(ctx)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	return sql.ScanSlice(rows, v)
+}
+
+func (dgb *DynamicGroupBy) sqlRows(ctx context.Context) (*sql.Rows, error) {
 	for _, f := range dgb.fields {
 		if !dgb.table.HasColumn(f) {
-			return &ValidationError{Name: f, err: fmt.Errorf("invalid field %q for group-by", f)}
+			return nil, &ValidationError{Name: f, err: fmt.Errorf("invalid field %q for group-by", f)}
 		}
 	}
 	selector := dgb.sqlQuery()
 	if err := selector.Err(); err != nil {
-		return err
+		return nil, err
 	}
 	rows := &sql.Rows{}
 	query, args := selector.Query()
 	if err := dgb.table.driver.Query(ctx, query, args, rows); err != nil {
-		return err
+		return nil, err
 	}
-	defer rows.Close()
-	return sql.ScanSlice(rows, v)
+	return rows, nil
 }
 
 func (dgb *DynamicGroupBy) sqlQuery() *sql.Selector {
@@ -600,6 +744,16 @@ func (dgb *DynamicGroupBy) sqlQuery() *sql.Selector {
 		columns = append(columns, aggregation...)
 		selector.Select(columns...)
 	}
+	if len(dgb.having) > 0 {
+		having := selector.Clone().SetP(nil)
+		for _, p := range dgb.having {
+			p(having)
+		}
+		if err := having.Err(); err != nil && selector.Err() == nil {
+			selector.AddError(err)
+		}
+		selector.Having(having.P())
+	}
 	return selector.GroupBy(selector.Columns(dgb.fields...)...)
 }
 
@@ -621,6 +775,9 @@ func (ds *DynamicSelect) Scan(ctx context.Context, v interface{}) error {
 }
 
 func (ds *DynamicSelect) sqlScan(ctx context.Context, v interface{}) error {
+	if err := ds.sql.Err(); err != nil {
+		return err
+	}
 	rows := &sql.Rows{}
 	query, args := ds.sql.Query()
 	if err := ds.table.driver.Query(ctx, query, args, rows); err != nil {
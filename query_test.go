@@ -0,0 +1,65 @@
+package dent
+
+import (
+	"context"
+	"testing"
+
+	"entgo.io/ent/dialect"
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+)
+
+func TestGroupBy(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("posts")
+	table.AddColumn(&schema.Column{Name: "user_id", Type: field.TypeInt})
+	table.AddColumn(&schema.Column{Name: "likes", Type: field.TypeInt, Nullable: true})
+	table.AddColumn(&schema.Column{Name: "title", Type: field.TypeString})
+	client := openTest(t, table)
+	posts := client.Table("posts")
+	posts.Create().SetValue("user_id", 1).SetValue("likes", 3).SetValue("title", "a").SaveX(ctx)
+	posts.Create().SetValue("user_id", 1).SetValue("likes", 4).SetValue("title", "b").SaveX(ctx)
+	posts.Create().SetValue("user_id", 2).SetValue("title", "c").SaveX(ctx)
+
+	// The sum of the likes of the second user is NULL.
+	maps, err := posts.Query().
+		Order(Asc("user_id")).
+		GroupBy("user_id").
+		Aggregate(As(Sum("likes"), "total"), As(Count(), "n"), As(StringAgg("title", `\`), "titles")).
+		Maps(ctx)
+	if err != nil {
+		t.Fatalf("failed grouping rows: %v", err)
+	}
+	if len(maps) != 2 {
+		t.Fatalf("unexpected groups: %v", maps)
+	}
+	if m := maps[0]; m["user_id"] != int64(1) || m["total"] != int64(7) || m["n"] != int64(2) || (m["titles"] != `a\b` && m["titles"] != `b\a`) {
+		t.Errorf("unexpected first group: %v", m)
+	}
+	if v, ok := maps[1]["total"]; !ok || v != nil || maps[1]["n"] != int64(1) {
+		t.Errorf("unexpected second group: %v", maps[1])
+	}
+	nodes, err := posts.Query().
+		GroupBy("user_id").
+		Aggregate(As(Count(), "n")).
+		Having(AggregateGT(Count(), 1)).
+		Dynamics(ctx)
+	if err != nil {
+		t.Fatalf("failed grouping rows: %v", err)
+	}
+	if len(nodes) != 1 || nodes[0].Row["user_id"] != int64(1) {
+		t.Errorf("unexpected groups: %v", nodes)
+	}
+}
+
+func TestStringAgg(t *testing.T) {
+	fn := StringAgg("name", `\'`)
+	s := sql.Dialect(dialect.MySQL).Select().From(sql.Table("users"))
+	// Aggregations are evaluated for every query they are used in.
+	for i := 0; i < 2; i++ {
+		if got, want := fn(s), "GROUP_CONCAT(`users`.`name` SEPARATOR '\\\\''')"; got != want {
+			t.Errorf("StringAgg = %s, want %s", got, want)
+		}
+	}
+}
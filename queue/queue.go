@@ -0,0 +1,329 @@
+// Package queue implements a job queue on top of a dent table. Jobs are enqueued
+// in the table, and claimed by workers with locked queries that skip the jobs
+// that are claimed by other workers (SELECT ... FOR UPDATE SKIP LOCKED).
+//
+// Failed jobs are retried with a backoff, and are moved to the dead-letter
+// status when they run out of attempts. Claimed jobs that are not finished
+// within the visibility timeout (e.g. of a crashed worker) are claimed again.
+//
+//	q := queue.New(client)
+//	if err := q.Create(ctx); err != nil {
+//		return err
+//	}
+//	if _, err := q.Enqueue(ctx, "emails", payload); err != nil {
+//		return err
+//	}
+//	err := q.Run(ctx, "emails", func(ctx context.Context, job *queue.Job) error {
+//		return send(ctx, job.Payload)
+//	})
+//
+// MySQL and PostgreSQL support any number of concurrent workers. SQLite does not
+// support row locking, and its queues run a single worker.
+package queue
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+	"github.com/go-kenka/dent"
+)
+
+// Status of jobs.
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusDone    = "done"
+	StatusDead    = "dead"
+)
+
+// Columns of the jobs table.
+const (
+	FieldQueue       = "queue"
+	FieldPayload     = "payload"
+	FieldStatus      = "status"
+	FieldAttempts    = "attempts"
+	FieldMaxAttempts = "max_attempts"
+	FieldRunAt       = "run_at"
+	FieldLockedUntil = "locked_until"
+	FieldLastError   = "last_error"
+	FieldCreatedAt   = "created_at"
+	FieldUpdatedAt   = "updated_at"
+)
+
+// Job is a job of the queue.
+type Job struct {
+	ID          int
+	Queue       string
+	Payload     []byte
+	Status      string
+	Attempts    int
+	MaxAttempts int
+	RunAt       time.Time
+	LastError   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Handler handles the jobs of a queue. Returning an error (or panicking) fails the
+// attempt of the job. The context of the handler expires with the visibility timeout,
+// and is canceled when the worker stops.
+type Handler func(context.Context, *Job) error
+
+// Queue is a job queue that is stored in a table of the client.
+type Queue struct {
+	client            *dent.Client
+	table             *schema.Table
+	concurrency       int
+	pollInterval      time.Duration
+	visibilityTimeout time.Duration
+	maxAttempts       int
+	backoff           func(attempt int) time.Duration
+}
+
+// Option configures the queue.
+type Option func(*Queue)
+
+// WithTable sets the name of the jobs table. Defaults to "dent_jobs".
+func WithTable(name string) Option {
+	return func(q *Queue) {
+		q.table = newTable(name)
+	}
+}
+
+// WithConcurrency sets the number of jobs that are handled concurrently by a
+// worker. Defaults to 1, and is always 1 on SQLite.
+func WithConcurrency(n int) Option {
+	return func(q *Queue) {
+		q.concurrency = n
+	}
+}
+
+// WithPollInterval sets the interval of claiming jobs when the queue is empty. Defaults to 1s.
+func WithPollInterval(d time.Duration) Option {
+	return func(q *Queue) {
+		q.pollInterval = d
+	}
+}
+
+// WithVisibilityTimeout sets the time a claimed job is hidden from other workers,
+// after which it is claimed again if it was not finished. Defaults to 5m.
+func WithVisibilityTimeout(d time.Duration) Option {
+	return func(q *Queue) {
+		q.visibilityTimeout = d
+	}
+}
+
+// WithMaxAttempts sets the default number of attempts of jobs. Defaults to 5.
+// It panics if n is less than 1.
+func WithMaxAttempts(n int) Option {
+	checkAttempts(n)
+	return func(q *Queue) {
+		q.maxAttempts = n
+	}
+}
+
+// WithBackoff sets the delay of retrying a job after the given failed attempt
+// (starting at 1). Defaults to an exponential backoff of 1s, 2s, 4s... up to 1h.
+func WithBackoff(fn func(attempt int) time.Duration) Option {
+	return func(q *Queue) {
+		q.backoff = fn
+	}
+}
+
+// New returns a queue of the client, and registers its jobs table in the client.
+// The table is not created in the database (see Create).
+func New(client *dent.Client, opts ...Option) *Queue {
+	q := &Queue{
+		client:            client,
+		table:             newTable("dent_jobs"),
+		concurrency:       1,
+		pollInterval:      time.Second,
+		visibilityTimeout: 5 * time.Minute,
+		maxAttempts:       5,
+		backoff:           exponentialBackoff,
+	}
+	for _, opt := range opts {
+		opt(q)
+	}
+	if q.concurrency < 1 || q.singleWorker() {
+		q.concurrency = 1
+	}
+	client.AddTable(q.table)
+	return q
+}
+
+// newTable returns the schema of the jobs table.
+func newTable(name string) *schema.Table {
+	t := dent.NewTable(name)
+	t.AddColumn(&schema.Column{Name: FieldQueue, Type: field.TypeString, Size: 255})
+	t.AddColumn(&schema.Column{Name: FieldPayload, Type: field.TypeBytes, Nullable: true})
+	t.AddColumn(&schema.Column{Name: FieldStatus, Type: field.TypeString, Size: 16})
+	t.AddColumn(&schema.Column{Name: FieldAttempts, Type: field.TypeInt, Default: 0})
+	t.AddColumn(&schema.Column{Name: FieldMaxAttempts, Type: field.TypeInt})
+	t.AddColumn(&schema.Column{Name: FieldRunAt, Type: field.TypeTime})
+	t.AddColumn(&schema.Column{Name: FieldLockedUntil, Type: field.TypeTime, Nullable: true})
+	t.AddColumn(&schema.Column{Name: FieldLastError, Type: field.TypeString, Size: math.MaxInt32, Nullable: true})
+	t.AddColumn(&schema.Column{Name: FieldCreatedAt, Type: field.TypeTime})
+	t.AddColumn(&schema.Column{Name: FieldUpdatedAt, Type: field.TypeTime})
+	t.AddIndex(name+"_claim", false, []string{FieldQueue, FieldStatus, FieldRunAt})
+	return t
+}
+
+// exponentialBackoff is the default backoff of the queue.
+func exponentialBackoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	if attempt > 13 {
+		return time.Hour
+	}
+	if d := time.Second << uint(attempt-1); d < time.Hour {
+		return d
+	}
+	return time.Hour
+}
+
+// Create creates the jobs table in the database, or migrates it to the
+// schema of the queue if it exists.
+func (q *Queue) Create(ctx context.Context, opts ...schema.MigrateOption) error {
+	return q.client.Schema.Create(ctx, q.table, opts...)
+}
+
+// EnqueueOption configures an enqueued job.
+type EnqueueOption func(*dent.DCreate)
+
+// Delay delays the first attempt of the job by the given duration.
+func Delay(d time.Duration) EnqueueOption {
+	return func(dc *dent.DCreate) {
+		dc.SetValue(FieldRunAt, now().Add(d))
+	}
+}
+
+// MaxAttempts sets the number of attempts of the job, overriding the default of the queue.
+// It panics if n is less than 1.
+func MaxAttempts(n int) EnqueueOption {
+	checkAttempts(n)
+	return func(dc *dent.DCreate) {
+		dc.SetValue(FieldMaxAttempts, n)
+	}
+}
+
+// checkAttempts panics if n is not a valid number of attempts, as jobs
+// without attempts would never run.
+func checkAttempts(n int) {
+	if n < 1 {
+		panic(fmt.Sprintf("queue: invalid number of attempts %d", n))
+	}
+}
+
+// Enqueue adds a job with the given payload to the named queue.
+func (q *Queue) Enqueue(ctx context.Context, queue string, payload []byte, opts ...EnqueueOption) (*Job, error) {
+	return q.enqueue(ctx, q.client, queue, payload, opts...)
+}
+
+// EnqueueTx is like Enqueue, but adds the job in the given transaction,
+// so the job is only visible to the workers if the transaction commits.
+func (q *Queue) EnqueueTx(ctx context.Context, tx *dent.Tx, queue string, payload []byte, opts ...EnqueueOption) (*Job, error) {
+	return q.enqueue(ctx, tx.Client(), queue, payload, opts...)
+}
+
+func (q *Queue) enqueue(ctx context.Context, client *dent.Client, queue string, payload []byte, opts ...EnqueueOption) (*Job, error) {
+	at := now()
+	dc := client.Table(q.table.Name).Create().
+		SetValue(FieldQueue, queue).
+		SetValue(FieldPayload, payload).
+		SetValue(FieldStatus, StatusPending).
+		SetValue(FieldAttempts, 0).
+		SetValue(FieldMaxAttempts, q.maxAttempts).
+		SetValue(FieldRunAt, at).
+		SetValue(FieldCreatedAt, at).
+		SetValue(FieldUpdatedAt, at)
+	for _, opt := range opts {
+		opt(dc)
+	}
+	d, err := dc.Save(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return q.get(ctx, client, d.ID)
+}
+
+// Get returns the job with the given id.
+func (q *Queue) Get(ctx context.Context, id int) (*Job, error) {
+	return q.get(ctx, q.client, id)
+}
+
+func (q *Queue) get(ctx context.Context, client *dent.Client, id int) (*Job, error) {
+	d, err := client.Table(q.table.Name).Query().Where(dent.ID(id)).Only(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newJob(d), nil
+}
+
+// Dead returns the dead-letter jobs of the named queue, that ran out of attempts.
+func (q *Queue) Dead(ctx context.Context, queue string) ([]*Job, error) {
+	nodes, err := q.client.Table(q.table.Name).Query().
+		Where(dent.StringEQ(FieldQueue, queue), dent.StringEQ(FieldStatus, StatusDead)).
+		Order(dent.Asc(dent.FieldID)).
+		All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*Job, len(nodes))
+	for i, n := range nodes {
+		jobs[i] = newJob(n)
+	}
+	return jobs, nil
+}
+
+// Retry moves the dead-letter job with the given id back to its queue,
+// with a new set of attempts.
+func (q *Queue) Retry(ctx context.Context, id int) error {
+	at := now()
+	n, err := q.client.Table(q.table.Name).Update().
+		Where(dent.ID(id), dent.StringEQ(FieldStatus, StatusDead)).
+		SetValue(FieldStatus, StatusPending).
+		SetValue(FieldAttempts, 0).
+		SetValue(FieldRunAt, at).
+		SetValue(FieldUpdatedAt, at).
+		Save(ctx)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("queue: dead job %d not found", id)
+	}
+	return nil
+}
+
+// newJob returns the job of the row.
+func newJob(d *dent.Dynamic) *Job {
+	j := &Job{ID: d.ID}
+	j.Queue, _ = d.Row[FieldQueue].(string)
+	if s, ok := d.Row[FieldPayload].(string); ok {
+		j.Payload = []byte(s)
+	}
+	j.Status, _ = d.Row[FieldStatus].(string)
+	if n, ok := d.Row[FieldAttempts].(int64); ok {
+		j.Attempts = int(n)
+	}
+	if n, ok := d.Row[FieldMaxAttempts].(int64); ok {
+		j.MaxAttempts = int(n)
+	}
+	j.RunAt, _ = d.Row[FieldRunAt].(time.Time)
+	j.LastError, _ = d.Row[FieldLastError].(string)
+	j.CreatedAt, _ = d.Row[FieldCreatedAt].(time.Time)
+	j.UpdatedAt, _ = d.Row[FieldUpdatedAt].(time.Time)
+	return j
+}
+
+// now returns the current time of the queue, that is
+// stored in UTC for comparing times in all databases.
+var now = func() time.Time {
+	return time.Now().UTC()
+}
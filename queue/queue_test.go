@@ -0,0 +1,443 @@
+package queue
+
+import (
+	"context"
+	stdsql "database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"entgo.io/ent/dialect"
+	"entgo.io/ent/dialect/sql"
+	"github.com/go-kenka/dent"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func openQueue(t *testing.T, opts ...Option) *Queue {
+	t.Helper()
+	// A database file, as the tables of shared in-memory databases are
+	// locked for the transactions of the worker (and not waited for).
+	client, err := dent.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "queue.db")+"?_fk=1&_busy_timeout=5000")
+	if err != nil {
+		t.Fatalf("failed opening connection to sqlite: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	opts = append([]Option{
+		WithPollInterval(10 * time.Millisecond),
+		WithBackoff(func(int) time.Duration { return 0 }),
+	}, opts...)
+	q := New(client, opts...)
+	if err := q.Create(context.Background()); err != nil {
+		t.Fatalf("failed creating jobs table: %v", err)
+	}
+	return q
+}
+
+// fakeClock sets the time of the queue to a fake clock during the test,
+// and returns the function that advances it.
+func fakeClock(t *testing.T) func(time.Duration) {
+	t.Helper()
+	var (
+		mu   sync.Mutex
+		at   = time.Date(2022, 8, 1, 10, 0, 0, 0, time.UTC)
+		prev = now
+	)
+	now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return at
+	}
+	t.Cleanup(func() { now = prev })
+	return func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		at = at.Add(d)
+	}
+}
+
+func TestQueue(t *testing.T) {
+	ctx := context.Background()
+	advance := fakeClock(t)
+	q := openQueue(t, WithMaxAttempts(3))
+	for _, p := range []string{"a", "b", "fail"} {
+		if _, err := q.Enqueue(ctx, "emails", []byte(p)); err != nil {
+			t.Fatalf("failed enqueuing job: %v", err)
+		}
+	}
+	panics, err := q.Enqueue(ctx, "emails", []byte("panic"), MaxAttempts(1))
+	if err != nil {
+		t.Fatalf("failed enqueuing job: %v", err)
+	}
+	later, err := q.Enqueue(ctx, "emails", []byte("later"), Delay(time.Hour))
+	if err != nil {
+		t.Fatalf("failed enqueuing job: %v", err)
+	}
+	other, err := q.Enqueue(ctx, "sms", []byte("other"))
+	if err != nil {
+		t.Fatalf("failed enqueuing job: %v", err)
+	}
+	// The worker is stopped by the last job of the queue, that is
+	// ready when the clock is advanced by the last failing attempt.
+	if _, err := q.Enqueue(ctx, "emails", []byte("stop"), Delay(30*time.Minute)); err != nil {
+		t.Fatalf("failed enqueuing job: %v", err)
+	}
+
+	var (
+		mu       sync.Mutex
+		attempts = make(map[string]int)
+	)
+	rctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	err = q.Run(rctx, "emails", func(ctx context.Context, job *Job) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts[string(job.Payload)]++
+		switch string(job.Payload) {
+		case "fail":
+			if job.Attempts == 3 {
+				advance(45 * time.Minute)
+			}
+			return fmt.Errorf("attempt %d failed", job.Attempts)
+		case "panic":
+			panic("oops")
+		case "stop":
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error of run: %v", err)
+	}
+	if attempts["a"] != 1 || attempts["b"] != 1 || attempts["fail"] != 3 || attempts["panic"] != 1 || attempts["stop"] != 1 {
+		t.Errorf("unexpected attempts: %v", attempts)
+	}
+
+	dead, err := q.Dead(ctx, "emails")
+	if err != nil {
+		t.Fatalf("failed querying dead jobs: %v", err)
+	}
+	if len(dead) != 2 {
+		t.Fatalf("unexpected number of dead jobs: %d", len(dead))
+	}
+	if j := dead[0]; string(j.Payload) != "fail" || j.Attempts != 3 || j.LastError != "attempt 3 failed" {
+		t.Errorf("unexpected dead job: %+v", j)
+	}
+	if j := dead[1]; j.ID != panics.ID || !strings.Contains(j.LastError, "panicked: oops") {
+		t.Errorf("unexpected dead job: %+v", j)
+	}
+	for _, j := range []*Job{later, other} {
+		j, err := q.Get(ctx, j.ID)
+		if err != nil || j.Status != StatusPending || j.Attempts != 0 {
+			t.Errorf("unexpected job that was not claimed: %+v, %v", j, err)
+		}
+	}
+
+	// Dead jobs are moved back to the queue with new attempts.
+	if err := q.Retry(ctx, dead[0].ID); err != nil {
+		t.Fatalf("failed retrying job: %v", err)
+	}
+	if err := q.Retry(ctx, later.ID); err == nil {
+		t.Error("expected error of retrying a job that is not dead")
+	}
+	jobs, err := q.claim(ctx, "emails", 10)
+	if err != nil || len(jobs) != 1 || jobs[0].ID != dead[0].ID || jobs[0].Attempts != 1 {
+		t.Fatalf("unexpected claimed jobs: %v, %v", jobs, err)
+	}
+	if err := q.finish(jobs[0], nil); err != nil {
+		t.Fatalf("failed finishing job: %v", err)
+	}
+	if j, err := q.Get(ctx, jobs[0].ID); err != nil || j.Status != StatusDone {
+		t.Errorf("unexpected finished job: %+v, %v", j, err)
+	}
+}
+
+func TestQueueVisibilityTimeout(t *testing.T) {
+	ctx := context.Background()
+	advance := fakeClock(t)
+	q := openQueue(t, WithVisibilityTimeout(time.Minute))
+	job, err := q.Enqueue(ctx, "emails", nil, MaxAttempts(2))
+	if err != nil {
+		t.Fatalf("failed enqueuing job: %v", err)
+	}
+	for i := 1; i <= 2; i++ {
+		jobs, err := q.claim(ctx, "emails", 1)
+		if err != nil || len(jobs) != 1 || jobs[0].Attempts != i {
+			t.Fatalf("unexpected claimed jobs of attempt %d: %v, %v", i, jobs, err)
+		}
+		// Jobs are hidden from other workers until their timeout expires.
+		if jobs, err := q.claim(ctx, "emails", 1); err != nil || len(jobs) != 0 {
+			t.Fatalf("unexpected claimed jobs: %v, %v", jobs, err)
+		}
+		advance(time.Minute)
+	}
+	// The result of an expired attempt is ignored if the job was claimed again.
+	if err := q.finish(&Job{ID: job.ID, Attempts: 1, MaxAttempts: 2}, nil); err != nil {
+		t.Fatalf("failed finishing job: %v", err)
+	}
+	if jobs, err := q.claim(ctx, "emails", 1); err != nil || len(jobs) != 0 {
+		t.Fatalf("unexpected claimed jobs: %v, %v", jobs, err)
+	}
+	job, err = q.Get(ctx, job.ID)
+	if err != nil || job.Status != StatusDead || job.LastError != errVisibilityTimeout {
+		t.Errorf("unexpected job after its last attempt expired: %+v, %v", job, err)
+	}
+}
+
+func TestQueueShutdown(t *testing.T) {
+	ctx := context.Background()
+	q := openQueue(t)
+	job, err := q.Enqueue(ctx, "emails", nil, MaxAttempts(1))
+	if err != nil {
+		t.Fatalf("failed enqueuing job: %v", err)
+	}
+	rctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	err = q.Run(rctx, "emails", func(ctx context.Context, _ *Job) error {
+		cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error of run: %v", err)
+	}
+	// The interrupted attempt is not counted.
+	job, err = q.Get(ctx, job.ID)
+	if err != nil || job.Status != StatusPending || job.Attempts != 0 {
+		t.Fatalf("unexpected job after shutdown: %+v, %v", job, err)
+	}
+	node, err := q.client.Table(q.table.Name).Query().Where(dent.ID(job.ID)).Only(ctx)
+	if err != nil {
+		t.Fatalf("failed querying job: %v", err)
+	}
+	if locked, _ := node.Row[FieldLockedUntil].(time.Time); !locked.IsZero() {
+		t.Errorf("unexpected lock of released job: %v", locked)
+	}
+	if jobs, err := q.claim(ctx, "emails", 1); err != nil || len(jobs) != 1 || jobs[0].Attempts != 1 {
+		t.Errorf("unexpected claimed jobs: %v, %v", jobs, err)
+	}
+}
+
+func TestQueueAttempts(t *testing.T) {
+	ctx := context.Background()
+	q := openQueue(t)
+	// Pending jobs are claimed even if they have no attempts left.
+	node, err := q.client.Table(q.table.Name).Create().
+		SetValue(FieldQueue, "emails").
+		SetValue(FieldStatus, StatusPending).
+		SetValue(FieldAttempts, 0).
+		SetValue(FieldMaxAttempts, 0).
+		SetValue(FieldRunAt, now()).
+		SetValue(FieldCreatedAt, now()).
+		SetValue(FieldUpdatedAt, now()).
+		Save(ctx)
+	if err != nil {
+		t.Fatalf("failed creating job: %v", err)
+	}
+	if jobs, err := q.claim(ctx, "emails", 1); err != nil || len(jobs) != 1 || jobs[0].ID != node.ID {
+		t.Errorf("unexpected claimed jobs: %v, %v", jobs, err)
+	}
+	for _, fn := range []func(){
+		func() { WithMaxAttempts(0) },
+		func() { MaxAttempts(-1) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("expected panic of invalid attempts")
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestQueueClaimLock(t *testing.T) {
+	ctx := context.Background()
+	db, err := stdsql.Open("sqlite3", "file:TestQueueClaimLock?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("failed opening connection to sqlite: %v", err)
+	}
+	defer db.Close()
+	for _, d := range []string{dialect.MySQL, dialect.Postgres} {
+		// The statements are built for the dialect, and fail on execution.
+		var queries []string
+		client := dent.NewClient(dent.Driver(sql.OpenDB(d, db)), dent.Debug(), dent.Log(func(v ...interface{}) {
+			queries = append(queries, fmt.Sprint(v...))
+		}))
+		q := New(client, WithConcurrency(4))
+		if q.concurrency != 4 {
+			t.Errorf("%s: unexpected concurrency: %d", d, q.concurrency)
+		}
+		if _, err := q.claim(ctx, "emails", 4); err == nil {
+			t.Errorf("%s: expected error of claim statement on sqlite", d)
+		}
+		var claim string
+		for _, query := range queries {
+			if strings.Contains(query, "SELECT") {
+				claim = query
+			}
+		}
+		if !strings.Contains(claim, "ORDER BY") || !strings.Contains(claim, "LIMIT 4 FOR UPDATE SKIP LOCKED") {
+			t.Errorf("%s: expected locked claim statement in queries: %v", d, queries)
+		}
+	}
+}
+
+// lockDriver runs the statements of the MySQL dialect on SQLite without
+// their row locks, for running concurrent handlers of the queue.
+type lockDriver struct {
+	dialect.Driver
+	mu     sync.Mutex
+	claims int
+	// fail returns the error of the nth claim.
+	fail func(n int) error
+}
+
+func (d *lockDriver) Dialect() string { return dialect.MySQL }
+
+func (d *lockDriver) Tx(ctx context.Context) (dialect.Tx, error) {
+	tx, err := d.Driver.Tx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &lockTx{Tx: tx, drv: d}, nil
+}
+
+func (d *lockDriver) query(query string) (string, error) {
+	if !strings.HasSuffix(query, " FOR UPDATE SKIP LOCKED") {
+		return query, nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.claims++; d.fail != nil {
+		if err := d.fail(d.claims); err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimSuffix(query, " FOR UPDATE SKIP LOCKED"), nil
+}
+
+type lockTx struct {
+	dialect.Tx
+	drv *lockDriver
+}
+
+func (tx *lockTx) Query(ctx context.Context, query string, args, v interface{}) error {
+	query, err := tx.drv.query(query)
+	if err != nil {
+		return err
+	}
+	return tx.Tx.Query(ctx, query, args, v)
+}
+
+// openLocked opens a queue whose client runs on the lockDriver.
+func openLocked(t *testing.T, drv *lockDriver, opts ...Option) *Queue {
+	t.Helper()
+	// Transactions take the write lock when they start, for not failing
+	// the claims that run along the updates of the finished jobs.
+	db, err := stdsql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "queue.db")+"?_fk=1&_busy_timeout=5000&_txlock=immediate")
+	if err != nil {
+		t.Fatalf("failed opening connection to sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	drv.Driver = sql.OpenDB(dialect.SQLite, db)
+	if err := New(dent.NewClient(dent.Driver(drv.Driver))).Create(context.Background()); err != nil {
+		t.Fatalf("failed creating jobs table: %v", err)
+	}
+	opts = append([]Option{
+		WithPollInterval(10 * time.Millisecond),
+		WithBackoff(func(int) time.Duration { return 0 }),
+	}, opts...)
+	return New(dent.NewClient(dent.Driver(drv)), opts...)
+}
+
+func TestQueueConcurrency(t *testing.T) {
+	ctx := context.Background()
+	q := openLocked(t, &lockDriver{}, WithConcurrency(3))
+	if q.concurrency != 3 {
+		t.Fatalf("unexpected concurrency: %d", q.concurrency)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := q.Enqueue(ctx, "emails", []byte(fmt.Sprint(i))); err != nil {
+			t.Fatalf("failed enqueuing job: %v", err)
+		}
+	}
+	var (
+		started = make(chan struct{})
+		release = make(chan struct{})
+		errc    = make(chan error, 1)
+	)
+	rctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	go func() {
+		errc <- q.Run(rctx, "emails", func(ctx context.Context, job *Job) error {
+			started <- struct{}{}
+			select {
+			case <-release:
+			case <-ctx.Done():
+				// The jobs are released before the worker is stopped.
+				select {
+				case <-release:
+				default:
+					return ctx.Err()
+				}
+			}
+			return nil
+		})
+	}()
+	// All jobs are running at once.
+	for i := 0; i < 3; i++ {
+		select {
+		case <-started:
+		case err := <-errc:
+			t.Fatalf("unexpected error of run: %v", err)
+		}
+	}
+	close(release)
+	cancel()
+	if err := <-errc; !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error of run: %v", err)
+	}
+	n, err := q.client.Table(q.table.Name).Query().Where(dent.StringEQ(FieldStatus, StatusDone)).Count(ctx)
+	if err != nil || n != 3 {
+		t.Errorf("unexpected number of done jobs: %d, %v", n, err)
+	}
+}
+
+func TestQueueClaimError(t *testing.T) {
+	ctx := context.Background()
+	errClaim := errors.New("claim failed")
+	q := openLocked(t, &lockDriver{
+		fail: func(n int) error {
+			if n > 1 {
+				return errClaim
+			}
+			return nil
+		},
+	}, WithConcurrency(2))
+	job, err := q.Enqueue(ctx, "emails", nil)
+	if err != nil {
+		t.Fatalf("failed enqueuing job: %v", err)
+	}
+	rctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	// The running handler is stopped when the worker fails.
+	err = q.Run(rctx, "emails", func(ctx context.Context, _ *Job) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, errClaim) {
+		t.Fatalf("unexpected error of run: %v", err)
+	}
+	if rctx.Err() != nil {
+		t.Fatalf("unexpected timeout of run: %v", rctx.Err())
+	}
+	job, err = q.Get(ctx, job.ID)
+	if err != nil || job.Status != StatusPending || job.Attempts != 0 {
+		t.Errorf("unexpected job after the worker failed: %+v, %v", job, err)
+	}
+}
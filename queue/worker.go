@@ -0,0 +1,253 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"entgo.io/ent/dialect"
+	"entgo.io/ent/dialect/sql"
+	"github.com/go-kenka/dent"
+)
+
+// errVisibilityTimeout is the error of jobs that were not finished within
+// the visibility timeout of their last attempt.
+const errVisibilityTimeout = "queue: visibility timeout expired"
+
+// finishTimeout bounds the update that records the result of an attempt, that
+// runs even if the context of the worker was canceled.
+const finishTimeout = 10 * time.Second
+
+// Run claims the jobs of the named queue and handles them with h, running up to the
+// concurrency of the queue (see WithConcurrency) at once. Run blocks until the context
+// is canceled, or claiming or finishing a job fails, and returns the error after the
+// running handlers return. The context of the running handlers is canceled when Run
+// returns, and jobs whose handlers fail because it was canceled (e.g. on shutdown)
+// are released to the queue without counting their attempt.
+func (q *Queue) Run(ctx context.Context, queue string, h Handler) error {
+	ctx, cancel := context.WithCancel(ctx)
+	var (
+		wg    sync.WaitGroup
+		sem   = make(chan struct{}, q.concurrency)
+		done  = make(chan struct{}, q.concurrency)
+		errc  = make(chan error, 1)
+		timer = time.NewTimer(q.pollInterval)
+	)
+	defer wg.Wait()
+	// The handlers are stopped before they are waited for.
+	defer cancel()
+	defer timer.Stop()
+	for {
+		if free := q.concurrency - len(sem); free > 0 {
+			jobs, err := q.claim(ctx, queue, free)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				return err
+			}
+			for _, job := range jobs {
+				sem <- struct{}{}
+				wg.Add(1)
+				go func(job *Job) {
+					defer func() {
+						<-sem
+						wg.Done()
+						select {
+						case done <- struct{}{}:
+						default:
+						}
+					}()
+					herr := q.handle(ctx, h, job)
+					var err error
+					if herr != nil && ctx.Err() != nil {
+						err = q.release(job)
+					} else {
+						err = q.finish(job, herr)
+					}
+					if err != nil {
+						select {
+						case errc <- err:
+						default:
+						}
+					}
+				}(job)
+			}
+		}
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(q.pollInterval)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-errc:
+			return err
+		case <-done:
+		case <-timer.C:
+		}
+	}
+}
+
+// singleWorker reports if the database of the queue does not support
+// row locking, and its jobs must be claimed by a single worker.
+func (q *Queue) singleWorker() bool {
+	return q.client.Dialect() == dialect.SQLite
+}
+
+// claim claims up to n jobs of the queue that are ready to run, or whose visibility
+// timeout expired, and hides them from other workers for the visibility timeout.
+//
+//	SELECT * FROM `dent_jobs` WHERE `queue` = ? AND ((`status` = 'pending' AND `run_at` <= ?) OR (`status` = 'running' AND `locked_until` <= ?))
+//	ORDER BY `run_at`, `id` LIMIT ? FOR UPDATE SKIP LOCKED
+func (q *Queue) claim(ctx context.Context, queue string, n int) ([]*Job, error) {
+	tx, err := q.client.Tx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	jobs, err := q.claimTx(ctx, tx.Client(), queue, n)
+	if err != nil {
+		if rerr := tx.Rollback(); rerr != nil {
+			err = fmt.Errorf("%w: %v", err, rerr)
+		}
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (q *Queue) claimTx(ctx context.Context, client *dent.Client, queue string, n int) ([]*Job, error) {
+	at := now()
+	query := client.Table(q.table.Name).Query().
+		Where(
+			dent.StringEQ(FieldQueue, queue),
+			dent.Or(
+				dent.And(dent.StringEQ(FieldStatus, StatusPending), dent.TimeLTE(FieldRunAt, at)),
+				dent.And(dent.StringEQ(FieldStatus, StatusRunning), dent.TimeLTE(FieldLockedUntil, at)),
+			),
+		).
+		Order(dent.Asc(FieldRunAt, dent.FieldID)).
+		Limit(n)
+	if !q.singleWorker() {
+		query.ForUpdate(sql.WithLockAction(sql.SkipLocked))
+	}
+	nodes, err := query.All(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		jobs []*Job
+		ids  []int
+		dead []int
+	)
+	for _, n := range nodes {
+		job := newJob(n)
+		// Jobs of expired attempts are claimed again,
+		// unless it was the last attempt of the job.
+		if job.Status == StatusRunning && job.Attempts >= job.MaxAttempts {
+			dead = append(dead, job.ID)
+			continue
+		}
+		job.Status = StatusRunning
+		job.Attempts++
+		job.UpdatedAt = at
+		jobs = append(jobs, job)
+		ids = append(ids, job.ID)
+	}
+	if len(dead) > 0 {
+		err := client.Table(q.table.Name).Update().
+			Where(dent.IDIn(dead...)).
+			SetValue(FieldStatus, StatusDead).
+			SetValue(FieldLastError, errVisibilityTimeout).
+			SetValue(FieldUpdatedAt, at).
+			ClearValue(FieldLockedUntil).
+			Exec(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(ids) > 0 {
+		err := client.Table(q.table.Name).Update().
+			Where(dent.IDIn(ids...)).
+			SetValue(FieldStatus, StatusRunning).
+			AddValue(FieldAttempts, 1).
+			SetValue(FieldLockedUntil, at.Add(q.visibilityTimeout)).
+			SetValue(FieldUpdatedAt, at).
+			Exec(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return jobs, nil
+}
+
+// handle runs the handler of the job, with a context that expires with
+// the visibility timeout of the job, and recovers from its panics.
+func (q *Queue) handle(ctx context.Context, h Handler, job *Job) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, q.visibilityTimeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("queue: job %d panicked: %v", job.ID, r)
+		}
+	}()
+	return h(ctx, job)
+}
+
+// finish records the result of the attempt of the job. Failed jobs are scheduled
+// for another attempt after the backoff of the queue, or moved to the dead-letter
+// status if it was their last attempt. The update is skipped if the job was
+// claimed again after its visibility timeout expired.
+func (q *Queue) finish(job *Job, herr error) error {
+	at := now()
+	update := q.client.Table(q.table.Name).Update().
+		Where(attempt(job)).
+		SetValue(FieldUpdatedAt, at).
+		ClearValue(FieldLockedUntil)
+	switch {
+	case herr == nil:
+		update.SetValue(FieldStatus, StatusDone)
+	case job.Attempts >= job.MaxAttempts:
+		update.SetValue(FieldStatus, StatusDead).SetValue(FieldLastError, herr.Error())
+	default:
+		update.SetValue(FieldStatus, StatusPending).
+			SetValue(FieldRunAt, at.Add(q.backoff(job.Attempts))).
+			SetValue(FieldLastError, herr.Error())
+	}
+	// The result is recorded even if the context of
+	// the worker was canceled while the job was running.
+	ctx, cancel := context.WithTimeout(context.Background(), finishTimeout)
+	defer cancel()
+	return update.Exec(ctx)
+}
+
+// release returns the job to the queue without counting its attempt, when the attempt
+// was interrupted by the worker. Like finish, the update is skipped if the job was
+// claimed again.
+func (q *Queue) release(job *Job) error {
+	ctx, cancel := context.WithTimeout(context.Background(), finishTimeout)
+	defer cancel()
+	return q.client.Table(q.table.Name).Update().
+		Where(attempt(job)).
+		SetValue(FieldStatus, StatusPending).
+		SetValue(FieldAttempts, job.Attempts-1).
+		SetValue(FieldUpdatedAt, now()).
+		ClearValue(FieldLockedUntil).
+		Exec(ctx)
+}
+
+// attempt returns the predicate of the row of the job, as long as it
+// is running the attempt that the job was claimed with.
+func attempt(job *Job) dent.Predicate {
+	return dent.And(
+		dent.ID(job.ID),
+		dent.StringEQ(FieldStatus, StatusRunning),
+		dent.IntEQ(FieldAttempts, job.Attempts),
+	)
+}
@@ -0,0 +1,137 @@
+package dent
+
+import (
+	"context"
+	stdsql "database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"time"
+
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/schema"
+)
+
+// QueryRaw executes a raw SQL query and returns its rows as Dynamic entities of
+// the given table. Columns of the table are scanned with the types of the table
+// columns, and other columns (e.g. computed ones) with the column types that are
+// reported by the driver. The table can be empty for results that do not belong
+// to any registered table.
+//
+//	rows, err := client.QueryRaw(ctx, "user", "SELECT *, LENGTH(name) AS len FROM users WHERE age > ?", 30)
+func (c *Client) QueryRaw(ctx context.Context, table, query string, args ...interface{}) ([]*Dynamic, error) {
+	t := c.Table(table)
+	if t.Table == nil {
+		if table != "" {
+			return nil, &NotFoundError{table}
+		}
+		t.Table = &schema.Table{}
+	}
+	rows := &sql.Rows{}
+	if err := c.driver.Query(ctx, query, args, rows); err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return t.scanRows(rows)
+}
+
+// scanRows scans the rows into Dynamic entities of the table. Columns that do not
+// belong to the table are scanned with the column types reported by the driver.
+func (c *Table) scanRows(rows *sql.Rows) ([]*Dynamic, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	var nodes []*Dynamic
+	for rows.Next() {
+		values, err := c.scanValues(columns)
+		if err != nil {
+			return nil, err
+		}
+		for i := range values {
+			if values[i] == nil {
+				values[i] = rawScanValue(types[i])
+			}
+		}
+		if err := rows.Scan(values...); err != nil {
+			return nil, err
+		}
+		node := c.newDynamic()
+		if err := node.assignValues(columns, values); err != nil {
+			return nil, err
+		}
+		for i, column := range columns {
+			if c.HasColumn(column) {
+				continue
+			}
+			if _, ok := c.joinedColumn(column); ok {
+				continue
+			}
+			v := reflect.ValueOf(values[i]).Elem().Interface()
+			switch vv := v.(type) {
+			case driver.Valuer:
+				if v, err = vv.Value(); err != nil {
+					return nil, err
+				}
+			case []byte:
+				v = string(vv)
+			}
+			if id, ok := v.(int64); ok && column == FieldID {
+				node.ID = int(id)
+				delete(node.Row, column)
+				continue
+			}
+			node.Row[column] = v
+		}
+		nodes = append(nodes, node)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// rawScanValue returns the scan destination of a column that does not belong
+// to the table, based on the type reported by the driver. Columns are scanned
+// into nullable types, as computed columns may be NULL even if the driver
+// reports a non-nullable type (e.g. SUM of no rows, or LEFT JOIN columns).
+func rawScanValue(ct *stdsql.ColumnType) interface{} {
+	typ := ct.ScanType()
+	if typ == nil {
+		return new(interface{})
+	}
+	ptr := reflect.PtrTo(typ)
+	if ptr.Implements(reflect.TypeOf((*stdsql.Scanner)(nil)).Elem()) && typ.Implements(reflect.TypeOf((*driver.Valuer)(nil)).Elem()) {
+		// Nullable types, like sql.NullInt64.
+		return reflect.New(typ).Interface()
+	}
+	switch typ.Kind() {
+	case reflect.Bool:
+		return new(stdsql.NullBool)
+	case reflect.String:
+		return new(stdsql.NullString)
+	case reflect.Float32, reflect.Float64:
+		return new(stdsql.NullFloat64)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return new(stdsql.NullInt64)
+	}
+	if typ == reflect.TypeOf(time.Time{}) {
+		return new(stdsql.NullTime)
+	}
+	return new(interface{})
+}
+
+// ExecRaw executes a raw SQL statement through the driver of the client,
+// including its transaction and debug logging, if any.
+func (c *Client) ExecRaw(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	var res sql.Result
+	if err := c.driver.Exec(ctx, query, args, &res); err != nil {
+		return nil, fmt.Errorf("ent: executing raw statement: %w", err)
+	}
+	return res, nil
+}
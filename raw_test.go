@@ -0,0 +1,49 @@
+package dent
+
+import (
+	"context"
+	"testing"
+
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+)
+
+func TestQueryRaw(t *testing.T) {
+	ctx := context.Background()
+	users := NewTable("users")
+	users.AddColumn(&schema.Column{Name: "name", Type: field.TypeString})
+	posts := NewTable("posts")
+	posts.AddColumn(&schema.Column{Name: "user_id", Type: field.TypeInt})
+	posts.AddColumn(&schema.Column{Name: "likes", Type: field.TypeInt})
+	posts.AddColumn(&schema.Column{Name: "title", Type: field.TypeString})
+	client := openTest(t, users, posts)
+	a := client.Table("users").Create().SetValue("name", "a").SaveX(ctx)
+	client.Table("users").Create().SetValue("name", "b").SaveX(ctx)
+	client.Table("posts").Create().SetValue("user_id", a.ID).SetValue("likes", 3).SetValue("title", "x").SaveX(ctx)
+
+	// Columns of the joined table are NULL for users without posts.
+	nodes, err := client.QueryRaw(ctx, "users", "SELECT users.*, posts.likes AS likes, posts.title AS title FROM users LEFT JOIN posts ON posts.user_id = users.id ORDER BY users.id")
+	if err != nil {
+		t.Fatalf("failed querying rows: %v", err)
+	}
+	if len(nodes) != 2 {
+		t.Fatalf("unexpected rows: %v", nodes)
+	}
+	if nodes[0].Row["name"] != "a" || nodes[0].Row["likes"] != int64(3) || nodes[0].Row["title"] != "x" {
+		t.Errorf("unexpected first row: %v", nodes[0].Row)
+	}
+	if v, ok := nodes[1].Row["likes"]; !ok || v != nil {
+		t.Errorf("expected NULL likes, got: %v", nodes[1].Row)
+	}
+	if v, ok := nodes[1].Row["title"]; !ok || v != nil {
+		t.Errorf("expected NULL title, got: %v", nodes[1].Row)
+	}
+	// Aggregations of no rows.
+	nodes, err = client.QueryRaw(ctx, "", "SELECT SUM(likes) AS total, COUNT(*) AS n FROM posts WHERE likes > ?", 10)
+	if err != nil {
+		t.Fatalf("failed querying rows: %v", err)
+	}
+	if len(nodes) != 1 || nodes[0].Row["total"] != nil || nodes[0].Row["n"] != int64(0) {
+		t.Errorf("unexpected aggregation: %v", nodes[0].Row)
+	}
+}
@@ -0,0 +1,323 @@
+package dent
+
+import (
+	"context"
+	"fmt"
+
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/sqlgraph"
+)
+
+// Relation is a named relation from a table to another registered table. The
+// relation is described like the edges of sqlgraph (see HasTable), where Table
+// is the table that holds the foreign-key columns (or the join table of M2M
+// relations), and To is the related table.
+type Relation struct {
+	Name    string
+	To      string
+	Rel     sqlgraph.Rel
+	Inverse bool
+	Table   string
+	Columns []string
+	// parent reports if the relation is the parent relation of a tree.
+	parent bool
+}
+
+// BelongsTo returns the relation to the row of the related table that is referenced
+// by the foreign-key column of the table. For example, the "author" of a post:
+//
+//	client.AddRelation("posts", BelongsTo("author", "users", "author_id"))
+func BelongsTo(name, to, column string) *Relation {
+	return &Relation{Name: name, To: to, Rel: sqlgraph.M2O, Inverse: true, Columns: []string{column}}
+}
+
+// HasOne returns the relation to the row of the related table that references
+// the table with its foreign-key column. For example, the "profile" of a user:
+//
+//	client.AddRelation("users", HasOne("profile", "profiles", "user_id"))
+func HasOne(name, to, column string) *Relation {
+	return &Relation{Name: name, To: to, Rel: sqlgraph.O2O, Table: to, Columns: []string{column}}
+}
+
+// HasMany returns the relation to the rows of the related table that reference
+// the table with their foreign-key column. For example, the "posts" of a user:
+//
+//	client.AddRelation("users", HasMany("posts", "posts", "author_id"))
+func HasMany(name, to, column string) *Relation {
+	return &Relation{Name: name, To: to, Rel: sqlgraph.O2M, Table: to, Columns: []string{column}}
+}
+
+// ManyToMany returns the relation to the rows of the related table through the join
+// table, where column references the table, and toColumn the related table. For
+// example, the "roles" of a user, and the "users" of a role:
+//
+//	client.AddRelation("users", ManyToMany("roles", "roles", "user_roles", "user_id", "role_id"))
+//	client.AddRelation("roles", ManyToMany("users", "users", "user_roles", "role_id", "user_id"))
+func ManyToMany(name, to, table, column, toColumn string) *Relation {
+	return &Relation{Name: name, To: to, Rel: sqlgraph.M2M, Table: table, Columns: []string{column, toColumn}}
+}
+
+// AddRelation registers the relations of the given table. Relations are
+// used for traversing from the rows of the table to their related rows.
+// The related table and the foreign-key table of relations default to the given table.
+func (c *Client) AddRelation(table string, relations ...*Relation) {
+	if c.rmap[table] == nil {
+		c.rmap[table] = make(map[string]*Relation)
+	}
+	for _, r := range relations {
+		if r.To == "" {
+			r.To = table
+		}
+		if r.Table == "" {
+			r.Table = table
+		}
+		c.rmap[table][r.Name] = r
+	}
+}
+
+// Relations returns the registered relations of the given table.
+func (c *Client) Relations(table string) []*Relation {
+	relations := make([]*Relation, 0, len(c.rmap[table]))
+	for _, r := range c.rmap[table] {
+		relations = append(relations, r)
+	}
+	return relations
+}
+
+// relation returns the registered relation of the table with the given name.
+func (c *Table) relation(name string) (*Relation, error) {
+	if c.client != nil {
+		if r, ok := c.client.rmap[c.Name][name]; ok {
+			if _, ok := c.client.tmap[r.To]; !ok {
+				return nil, &ValidationError{Name: name, err: fmt.Errorf("ent: unknown table %q of relation %q", r.To, name)}
+			}
+			return r, nil
+		}
+	}
+	return nil, &ValidationError{Name: name, err: fmt.Errorf("ent: unknown relation %q for table %q", name, c.Name)}
+}
+
+// relatedTable returns the builder of the related table of the relation.
+func (c *Table) relatedTable(r *Relation) *Table {
+	return &Table{config: c.config, Table: c.client.tmap[r.To], client: c.client}
+}
+
+// step returns the graph step of the relation from the given table node,
+// where the node is an ID or a selector of IDs.
+func (r *Relation) step(table string, from interface{}) *sqlgraph.Step {
+	return sqlgraph.NewStep(
+		sqlgraph.From(table, FieldID, from),
+		sqlgraph.To(r.To, FieldID),
+		sqlgraph.Edge(r.Rel, r.Inverse, r.Table, r.Columns...),
+	)
+}
+
+// queryRelation returns the query of the rows that are related with the relation of
+// the table, to the rows that are selected by the given path.
+func (c *Table) queryRelation(name string, path func(context.Context, *Relation) (*sql.Selector, error)) *DQuery {
+	r, err := c.relation(name)
+	if err != nil {
+		query := c.Query()
+		query.path = func(context.Context) (*sql.Selector, error) {
+			return nil, err
+		}
+		return query
+	}
+	query := c.relatedTable(r).Query()
+	query.path = func(ctx context.Context) (*sql.Selector, error) {
+		return path(ctx, r)
+	}
+	return query
+}
+
+// Query returns a query of the rows that are related to the entity with the given
+// relation of its table (see Client.AddRelation). The query can be filtered and
+// ordered like any other query, and traverse further relations of its table.
+//
+//	permissions, err := user.Query("roles").
+//		Where(BoolEQ("active", true)).
+//		Query("permissions").
+//		All(ctx)
+func (d *Dynamic) Query(relation string) *DQuery {
+	return d.table.queryRelation(relation, func(_ context.Context, r *Relation) (*sql.Selector, error) {
+		return sqlgraph.Neighbors(d.table.driver.Dialect(), r.step(d.table.Name, d.ID)), nil
+	})
+}
+
+// Query returns a query of the rows that are related to the rows of the query
+// with the given relation of its table (see Client.AddRelation).
+//
+//	client.Table("users").Query().
+//		Where(StringEQ("team", "core")).
+//		Query("roles").
+//		All(ctx)
+func (dq *DQuery) Query(relation string) *DQuery {
+	from := dq.Clone()
+	return dq.table.queryRelation(relation, func(ctx context.Context, r *Relation) (*sql.Selector, error) {
+		if err := from.prepareQuery(ctx); err != nil {
+			return nil, err
+		}
+		selector := from.sqlQuery(ctx)
+		if err := selector.Err(); err != nil {
+			return nil, err
+		}
+		return sqlgraph.SetNeighbors(from.table.driver.Dialect(), r.step(from.table.Name, selector)), nil
+	})
+}
+
+// HasRelatedWith filters the rows of the query to the rows that have related rows with the
+// given relation of the table (see Client.AddRelation), that match all the given predicates.
+// For example, the users that have an order above 100:
+//
+//	client.Table("users").Query().
+//		HasRelatedWith("orders", Field("amount").GT(100)).
+//		All(ctx)
+func (dq *DQuery) HasRelatedWith(relation string, ps ...Predicate) *DQuery {
+	t := dq.table
+	return dq.Where(func(s *sql.Selector) {
+		r, err := t.relation(relation)
+		if pc, ok := s.Context().Value(predicateCheckKey{}).(*predicateCheck); ok {
+			// The predicates are checked against the
+			// columns of the related table.
+			if err == nil {
+				related := t.relatedTable(r)
+				err = checkPredicates(related, related.Column, ps...)
+			}
+			if err != nil && pc.err == nil {
+				pc.err = err
+			}
+			return
+		}
+		if err != nil {
+			s.AddError(err)
+			return
+		}
+		sqlgraph.HasNeighborsWith(s, r.step(s.TableName(), nil), func(s *sql.Selector) {
+			for _, p := range ps {
+				p(s)
+			}
+		})
+	})
+}
+
+// OrderByRelated orders the rows of the query by the given field of their related row
+// with the given single relation of the table (BelongsTo or HasOne), in ascending order.
+// Since the field is not part of the selected columns, the query is not unique (i.e.
+// SELECT DISTINCT) unless configured otherwise with Unique. For example, the users
+// ordered by the name of their manager:
+//
+//	client.Table("users").Query().
+//		OrderByRelated("manager", "name").
+//		All(ctx)
+func (dq *DQuery) OrderByRelated(relation, field string) *DQuery {
+	return dq.orderByExpr(dq.table.orderByRelated(relation, field, false))
+}
+
+// OrderByRelatedDesc is like OrderByRelated, but orders the rows in descending order.
+func (dq *DQuery) OrderByRelatedDesc(relation, field string) *DQuery {
+	return dq.orderByExpr(dq.table.orderByRelated(relation, field, true))
+}
+
+// OrderByCount orders the rows of the query by the number of their related
+// rows with the given relation of the table, in ascending order. Like OrderByRelated,
+// the query is not unique unless configured otherwise with Unique.
+//
+//	client.Table("posts").Query().
+//		OrderByCountDesc("comments").
+//		All(ctx)
+func (dq *DQuery) OrderByCount(relation string) *DQuery {
+	return dq.orderByExpr(dq.table.orderByCount(relation, false))
+}
+
+// OrderByCountDesc is like OrderByCount, but orders the rows in descending order.
+func (dq *DQuery) OrderByCountDesc(relation string) *DQuery {
+	return dq.orderByExpr(dq.table.orderByCount(relation, true))
+}
+
+// orderByExpr adds the ordering by an expression that is not part of the selected columns.
+// Databases like PostgreSQL reject such orderings in SELECT DISTINCT queries, and the query
+// is not unique unless configured otherwise.
+func (dq *DQuery) orderByExpr(o OrderFunc) *DQuery {
+	if dq.unique == nil {
+		dq.Unique(false)
+	}
+	return dq.Order(o)
+}
+
+// orderByRelated returns the order of the rows by the field of their related row.
+//
+//	ORDER BY (SELECT `users_manager`.`name` FROM `users` AS `users_manager` WHERE `users_manager`.`id` = `users`.`manager_id`)
+func (c *Table) orderByRelated(relation, field string, desc bool) OrderFunc {
+	return func(s *sql.Selector) {
+		r, err := c.relation(relation)
+		if err != nil {
+			s.AddError(err)
+			return
+		}
+		if r.Rel != sqlgraph.M2O && r.Rel != sqlgraph.O2O {
+			s.AddError(&ValidationError{Name: relation, err: fmt.Errorf("ent: relation %q for ordering is not a single relation", relation)})
+			return
+		}
+		if _, ok := c.relatedTable(r).Column(field); !ok {
+			s.AddError(&ValidationError{Name: field, err: fmt.Errorf("ent: invalid field %q of relation %q for ordering", field, relation)})
+			return
+		}
+		builder := sql.Dialect(s.Dialect())
+		// The related table is aliased, as it may
+		// be the table of the rows (e.g. a tree).
+		to := builder.Table(r.To).As(c.Name + "_" + r.Name)
+		related := builder.Select(to.C(field)).From(to)
+		if r.Rel == sqlgraph.M2O || r.Inverse {
+			related.Where(sql.ColumnsEQ(to.C(FieldID), s.C(r.Columns[0])))
+		} else {
+			related.Where(sql.ColumnsEQ(to.C(r.Columns[0]), s.C(FieldID)))
+		}
+		s.OrderExpr(orderExpr(related, desc))
+	}
+}
+
+// orderByCount returns the order of the rows by the number of their related rows.
+//
+//	ORDER BY (SELECT COUNT(*) FROM `comments` AS `posts_comments` WHERE `posts_comments`.`post_id` = `posts`.`id`)
+func (c *Table) orderByCount(relation string, desc bool) OrderFunc {
+	return func(s *sql.Selector) {
+		r, err := c.relation(relation)
+		if err != nil {
+			s.AddError(err)
+			return
+		}
+		builder := sql.Dialect(s.Dialect())
+		var related *sql.Selector
+		switch {
+		case r.Rel == sqlgraph.M2M:
+			from := r.Columns[0]
+			if r.Inverse {
+				from = r.Columns[1]
+			}
+			join := builder.Table(r.Table).As(c.Name + "_" + r.Name)
+			related = builder.Select(sql.Count("*")).From(join).
+				Where(sql.ColumnsEQ(join.C(from), s.C(FieldID)))
+		case r.Rel == sqlgraph.M2O || (r.Rel == sqlgraph.O2O && r.Inverse):
+			to := builder.Table(r.To).As(c.Name + "_" + r.Name)
+			related = builder.Select(sql.Count("*")).From(to).
+				Where(sql.ColumnsEQ(to.C(FieldID), s.C(r.Columns[0])))
+		default:
+			to := builder.Table(r.Table).As(c.Name + "_" + r.Name)
+			related = builder.Select(sql.Count("*")).From(to).
+				Where(sql.ColumnsEQ(to.C(r.Columns[0]), s.C(FieldID)))
+		}
+		s.OrderExpr(orderExpr(related, desc))
+	}
+}
+
+// orderExpr returns the ordering expression of the scalar subquery.
+func orderExpr(q *sql.Selector, desc bool) sql.Querier {
+	return sql.ExprFunc(func(b *sql.Builder) {
+		b.Nested(func(b *sql.Builder) {
+			b.Join(q)
+		})
+		if desc {
+			b.WriteString(" DESC")
+		}
+	})
+}
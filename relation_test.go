@@ -0,0 +1,153 @@
+package dent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+)
+
+// openRelations opens a client with users, their roles
+// and the permissions of the roles.
+//
+//	a8m: admin, editor
+//	nati: editor
+//	ariel: -
+func openRelations(t *testing.T) (*Client, map[string]*Dynamic) {
+	t.Helper()
+	ctx := context.Background()
+	users := NewTable("users")
+	users.AddColumn(&schema.Column{Name: "name", Type: field.TypeString})
+	roles := NewTable("roles")
+	roles.AddColumn(&schema.Column{Name: "name", Type: field.TypeString})
+	userRoles := NewTable("user_roles")
+	userRoles.AddColumn(&schema.Column{Name: "user_id", Type: field.TypeInt})
+	userRoles.AddColumn(&schema.Column{Name: "role_id", Type: field.TypeInt})
+	permissions := NewTable("permissions")
+	permissions.AddColumn(&schema.Column{Name: "name", Type: field.TypeString})
+	permissions.AddColumn(&schema.Column{Name: "role_id", Type: field.TypeInt})
+	client := openTest(t, users, roles, userRoles, permissions)
+	client.AddRelation("users", ManyToMany("roles", "roles", "user_roles", "user_id", "role_id"))
+	client.AddRelation("roles",
+		ManyToMany("users", "users", "user_roles", "role_id", "user_id"),
+		HasMany("permissions", "permissions", "role_id"),
+	)
+	client.AddRelation("permissions", BelongsTo("role", "roles", "role_id"))
+
+	nodes := make(map[string]*Dynamic)
+	for _, name := range []string{"a8m", "nati", "ariel"} {
+		nodes[name] = client.Table("users").Create().SetValue("name", name).SaveX(ctx)
+	}
+	for _, name := range []string{"admin", "editor"} {
+		nodes[name] = client.Table("roles").Create().SetValue("name", name).SaveX(ctx)
+	}
+	for _, p := range [][2]string{{"a8m", "admin"}, {"a8m", "editor"}, {"nati", "editor"}} {
+		client.Table("user_roles").Create().SetValue("user_id", nodes[p[0]].ID).SetValue("role_id", nodes[p[1]].ID).SaveX(ctx)
+	}
+	for _, p := range [][2]string{{"delete", "admin"}, {"write", "admin"}, {"write", "editor"}, {"read", "editor"}} {
+		nodes[p[0]+"_"+p[1]] = client.Table("permissions").Create().SetValue("name", p[0]).SetValue("role_id", nodes[p[1]].ID).SaveX(ctx)
+	}
+	return client, nodes
+}
+
+// names returns the names of the rows of the query.
+func names(t *testing.T, q *DQuery) []string {
+	t.Helper()
+	nodes, err := q.All(context.Background())
+	if err != nil {
+		t.Fatalf("failed querying rows: %v", err)
+	}
+	names := make([]string, len(nodes))
+	for i, n := range nodes {
+		names[i] = n.Row["name"].(string)
+	}
+	return names
+}
+
+func TestQueryRelation(t *testing.T) {
+	ctx := context.Background()
+	client, nodes := openRelations(t)
+	a8m := client.Table("users").Query().Where(ID(nodes["a8m"].ID)).OnlyX(ctx)
+
+	if got := names(t, a8m.Query("roles").Order(Asc("name"))); !equalStrings(got, []string{"admin", "editor"}) {
+		t.Errorf("unexpected roles: %v", got)
+	}
+	// Traversals are chained with predicates and further relations.
+	got := names(t, a8m.Query("roles").Where(StringEQ("name", "editor")).Query("permissions").Order(Asc("name")))
+	if !equalStrings(got, []string{"read", "write"}) {
+		t.Errorf("unexpected permissions: %v", got)
+	}
+	got = names(t, a8m.Query("roles").Query("permissions").Where(StringEQ("name", "write")).Query("role").Query("users").Order(Asc("name")))
+	if !equalStrings(got, []string{"a8m", "nati"}) {
+		t.Errorf("unexpected users: %v", got)
+	}
+	n, err := client.Table("users").Query().Where(StringNEQ("name", "a8m")).Query("roles").Count(ctx)
+	if err != nil || n != 1 {
+		t.Errorf("unexpected count of roles: %d, %v", n, err)
+	}
+	ariel := client.Table("users").Query().Where(ID(nodes["ariel"].ID)).OnlyX(ctx)
+	if exist := ariel.Query("roles").ExistX(ctx); exist {
+		t.Error("expected no roles")
+	}
+	if _, err := a8m.Query("groups").All(ctx); !IsValidationError(err) {
+		t.Errorf("expected validation error of unknown relation, got: %v", err)
+	}
+}
+
+func TestOrderByRelated(t *testing.T) {
+	ctx := context.Background()
+	client, _ := openRelations(t)
+	var queries []string
+	client.log = func(v ...interface{}) {
+		queries = append(queries, fmt.Sprint(v...))
+	}
+	client = client.Debug()
+	permissions, users := client.Table("permissions"), client.Table("users")
+
+	for _, tt := range []struct {
+		name string
+		q    *DQuery
+		want []string
+	}{
+		{name: "related", q: permissions.Query().OrderByRelated("role", "name").Order(Asc("name")), want: []string{"delete", "write", "read", "write"}},
+		{name: "related desc", q: permissions.Query().OrderByRelatedDesc("role", "name").Order(Asc("name")), want: []string{"read", "write", "delete", "write"}},
+		{name: "count", q: users.Query().OrderByCount("roles").Order(Asc("name")), want: []string{"ariel", "nati", "a8m"}},
+		{name: "count desc", q: users.Query().OrderByCountDesc("roles").Order(Asc("name")), want: []string{"a8m", "nati", "ariel"}},
+		{name: "count of owner", q: client.Table("roles").Query().OrderByCountDesc("users"), want: []string{"editor", "admin"}},
+		{name: "related with", q: users.Query().HasRelatedWith("roles", StringEQ("name", "editor")).Order(Asc("name")), want: []string{"a8m", "nati"}},
+		{name: "related with admin", q: users.Query().HasRelatedWith("roles", StringEQ("name", "admin")), want: []string{"a8m"}},
+	} {
+		queries = nil
+		if got := names(t, tt.q); !equalStrings(got, tt.want) {
+			t.Errorf("%s: unexpected rows: %v", tt.name, got)
+		}
+		// Orderings by expressions are not supported
+		// by SELECT DISTINCT queries in PostgreSQL.
+		for _, q := range queries {
+			if strings.Contains(q, "ORDER BY (SELECT") && strings.Contains(q, "DISTINCT") {
+				t.Errorf("%s: unexpected distinct query: %s", tt.name, q)
+			}
+		}
+	}
+	queries = nil
+	users.Query().Unique(true).OrderByCount("roles").AllX(ctx)
+	if len(queries) != 1 || !strings.Contains(queries[0], "SELECT DISTINCT") {
+		t.Errorf("expected distinct query: %v", queries)
+	}
+
+	for _, q := range []*DQuery{
+		users.Query().OrderByRelated("roles", "name"),
+		permissions.Query().OrderByRelated("role", "nope"),
+		users.Query().OrderByCount("groups"),
+	} {
+		if _, err := q.All(ctx); err == nil {
+			t.Error("expected error of invalid ordering")
+		}
+	}
+	if _, err := users.Query().HasRelatedWith("roles", Field("nope").EQ("admin")).All(ctx); !IsValidationError(err) {
+		t.Errorf("expected validation error of unknown field of relation, got: %v", err)
+	}
+}
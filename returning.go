@@ -0,0 +1,190 @@
+package dent
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"entgo.io/ent/dialect"
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/sqlgraph"
+)
+
+// SaveReturning executes the update and returns the updated Dynamic entities ordered by
+// their IDs. It uses the RETURNING clause on PostgreSQL and SQLite. On MySQL, the matched
+// rows are locked and updated in a transaction, and read back after the update.
+func (du *DUpdate) SaveReturning(ctx context.Context) ([]*Dynamic, error) {
+	if err := du.defaults(); err != nil {
+		return nil, err
+	}
+	if err := du.check(); err != nil {
+		return nil, err
+	}
+	if err := du.mutation.checkTree(ctx); err != nil {
+		return nil, err
+	}
+	t := du.mutation.table
+	if len(du.mutation.data) == 0 && len(du.mutation.added) == 0 && len(du.mutation.clearedFields) == 0 {
+		return t.Query().Where(du.mutation.predicates...).Order(Asc(FieldID)).All(ctx)
+	}
+	if t.driver.Dialect() != dialect.MySQL {
+		update, err := du.sqlUpdate(du.selector(ctx))
+		if err != nil {
+			return nil, err
+		}
+		return t.returning(ctx, update)
+	}
+	var nodes []*Dynamic
+	err := t.withTx(ctx, func(t *Table) error {
+		ids, err := t.lockedIDs(ctx, du.mutation.predicates)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+		selector := sql.Dialect(t.driver.Dialect()).Select(FieldID).From(sql.Table(t.Name))
+		selector.Where(sql.InInts(FieldID, ids...))
+		update, err := du.sqlUpdate(selector)
+		if err != nil {
+			return err
+		}
+		if err := t.exec(ctx, update); err != nil {
+			return err
+		}
+		nodes, err = t.Query().Where(IDIn(ids...)).Order(Asc(FieldID)).All(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// SaveReturningX is like SaveReturning, but panics if an error occurs.
+func (du *DUpdate) SaveReturningX(ctx context.Context) []*Dynamic {
+	nodes, err := du.SaveReturning(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return nodes
+}
+
+// selector returns the selector of the rows matched by the update predicates.
+func (du *DUpdate) selector(ctx context.Context) *sql.Selector {
+	t := du.mutation.table
+	selector := sql.Dialect(t.driver.Dialect()).Select(FieldID).From(sql.Table(t.Name)).WithContext(ctx)
+	for _, p := range du.mutation.predicates {
+		p(selector)
+	}
+	return selector
+}
+
+// sqlUpdate returns the update statement of the mutation for the rows of the selector.
+// Values are encoded by the types of their columns, as in the update specs of Save.
+func (du *DUpdate) sqlUpdate(selector *sql.Selector) (*sql.UpdateBuilder, error) {
+	t := du.mutation.table
+	update := sql.Dialect(t.driver.Dialect()).Update(t.Name)
+	for k, v := range du.mutation.data {
+		col, _ := t.Column(k)
+		v, err := columnValue(valueType(col.Type, v), v)
+		if err != nil {
+			return nil, &ValidationError{Name: k, err: fmt.Errorf("ent: encoding value of field %q: %w", k, err)}
+		}
+		update.Set(k, v)
+	}
+	for k, v := range du.mutation.added {
+		update.Add(k, v)
+	}
+	for k := range du.mutation.clearedFields {
+		update.SetNull(k)
+	}
+	return update.FromSelect(selector), nil
+}
+
+// ExecReturning executes the deletion and returns the deleted Dynamic entities ordered by
+// their IDs. It uses the RETURNING clause on PostgreSQL and SQLite. On MySQL, the matched
+// rows are locked and read in a transaction, before they are deleted.
+func (dd *DDelete) ExecReturning(ctx context.Context) ([]*Dynamic, error) {
+	if err := dd.mutation.check(); err != nil {
+		return nil, err
+	}
+	t := dd.mutation.table
+	if t.driver.Dialect() != dialect.MySQL {
+		selector := sql.Dialect(t.driver.Dialect()).Select().From(sql.Table(t.Name)).WithContext(ctx)
+		for _, p := range dd.mutation.predicates {
+			p(selector)
+		}
+		return t.returning(ctx, sql.Dialect(t.driver.Dialect()).Delete(t.Name).FromSelect(selector))
+	}
+	var nodes []*Dynamic
+	err := t.withTx(ctx, func(t *Table) error {
+		var err error
+		nodes, err = t.Query().Where(dd.mutation.predicates...).Where(forUpdate).Order(Asc(FieldID)).All(ctx)
+		if err != nil || len(nodes) == 0 {
+			return err
+		}
+		ids := make([]int, len(nodes))
+		for i := range nodes {
+			ids[i] = nodes[i].ID
+		}
+		return t.exec(ctx, sql.Dialect(t.driver.Dialect()).Delete(t.Name).Where(sql.InInts(FieldID, ids...)))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return nodes, nil
+}
+
+// ExecReturningX is like ExecReturning, but panics if an error occurs.
+func (dd *DDelete) ExecReturningX(ctx context.Context) []*Dynamic {
+	nodes, err := dd.ExecReturning(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return nodes
+}
+
+// forUpdate locks the selected rows until the end of the transaction.
+func forUpdate(s *sql.Selector) {
+	s.ForUpdate()
+}
+
+// lockedIDs returns the IDs of the rows matching the predicates, and locks them
+// until the end of the transaction.
+func (c *Table) lockedIDs(ctx context.Context, ps []Predicate) ([]int, error) {
+	return c.Query().Where(ps...).Where(forUpdate).Order(Asc(FieldID)).IDs(ctx)
+}
+
+// returning executes the given statement with a RETURNING clause of all columns, and
+// returns the returned rows ordered by their IDs, as the order of RETURNING is undefined.
+func (c *Table) returning(ctx context.Context, stmt sql.Querier) ([]*Dynamic, error) {
+	query, args := stmt.Query()
+	b := &sql.Builder{}
+	b.SetDialect(c.driver.Dialect())
+	b.WriteString(query).WriteString(" RETURNING ").IdentComma(c.GetColumns()...)
+	rows := &sql.Rows{}
+	if err := c.driver.Query(ctx, b.String(), args, rows); err != nil {
+		if sqlgraph.IsConstraintError(err) {
+			err = &ConstraintError{msg: err.Error(), wrap: err}
+		}
+		return nil, err
+	}
+	defer rows.Close()
+	nodes, err := c.scanRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes, nil
+}
+
+// exec executes the given statement.
+func (c *Table) exec(ctx context.Context, stmt sql.Querier) error {
+	query, args := stmt.Query()
+	var res sql.Result
+	if err := c.driver.Exec(ctx, query, args, &res); err != nil {
+		if sqlgraph.IsConstraintError(err) {
+			err = &ConstraintError{msg: err.Error(), wrap: err}
+		}
+		return err
+	}
+	return nil
+}
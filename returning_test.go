@@ -0,0 +1,56 @@
+package dent
+
+import (
+	"context"
+	"testing"
+
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+)
+
+func TestSaveReturning(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("docs")
+	table.AddColumn(&schema.Column{Name: "title", Type: field.TypeString})
+	table.AddColumn(&schema.Column{Name: "meta", Type: field.TypeJSON, Nullable: true})
+	table.AddColumn(&schema.Column{Name: "views", Type: field.TypeInt})
+	client := openTest(t, table)
+	docs := client.Table("docs")
+	a := docs.Create().SetValue("title", "a").SetValue("views", 1).SaveX(ctx)
+	b := docs.Create().SetValue("title", "b").SetValue("views", 1).SaveX(ctx)
+	meta := map[string]interface{}{"tags": []string{"x"}}
+
+	docs.Update().Where(ID(a.ID)).SetValue("meta", meta).AddValue("views", 2).SaveX(ctx)
+	nodes := docs.Update().Where(ID(b.ID)).SetValue("meta", meta).AddValue("views", 2).SaveReturningX(ctx)
+	if len(nodes) != 1 || nodes[0].ID != b.ID {
+		t.Fatalf("unexpected returned rows: %v", nodes)
+	}
+	saved := docs.Query().Where(ID(a.ID)).OnlyX(ctx)
+	returned := docs.Query().Where(ID(b.ID)).OnlyX(ctx)
+	for _, column := range []string{"meta", "views"} {
+		if saved.Row[column] != returned.Row[column] {
+			t.Errorf("column %q: Save stored %#v, SaveReturning stored %#v", column, saved.Row[column], returned.Row[column])
+		}
+		if nodes[0].Row[column] != returned.Row[column] {
+			t.Errorf("column %q: SaveReturning returned %#v, stored %#v", column, nodes[0].Row[column], returned.Row[column])
+		}
+	}
+	if returned.Row["meta"] != `{"tags":["x"]}` {
+		t.Errorf("unexpected json value: %#v", returned.Row["meta"])
+	}
+
+	// Rows are returned in the order of their IDs.
+	c := docs.Create().SetValue("title", "c").SetValue("views", 1).SaveX(ctx)
+	nodes = docs.Update().Where(IDIn(c.ID, a.ID)).AddValue("views", 1).SaveReturningX(ctx)
+	if len(nodes) != 2 || nodes[0].ID != a.ID || nodes[1].ID != c.ID {
+		t.Errorf("unexpected returned rows: %v", nodes)
+	}
+
+	deleted := docs.Delete().Where(IDIn(c.ID, a.ID)).ExecReturningX(ctx)
+	if len(deleted) != 2 || deleted[0].Row["title"] != "a" || deleted[1].Row["title"] != "c" {
+		t.Errorf("unexpected deleted rows: %v", deleted)
+	}
+	if n := docs.Query().CountX(ctx); n != 1 {
+		t.Errorf("unexpected number of rows: %d", n)
+	}
+}
@@ -0,0 +1,176 @@
+package dent
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+
+	"entgo.io/ent"
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/sqlgraph"
+)
+
+// rollup is an aggregation of the related rows of the rows of a query.
+type rollup struct {
+	name       string
+	relation   string
+	field      string
+	fn         AggregateFunc
+	predicates []Predicate
+}
+
+// WithCount configures the query builder to count the rows that are related with the
+// given relation (see Client.AddRelation) to each row, without loading them. The
+// counts are stored as int64 values in the Aggregates of the rows, under the name
+// "<relation>_count". The predicates filter the counted rows, and replace the
+// predicates of a previous count of the relation.
+//
+//	posts, err := client.Table("posts").Query().
+//		WithCount("comments", BoolEQ("approved", true)).
+//		All(ctx)
+//	posts[0].Aggregates["comments_count"]
+func (dq *DQuery) WithCount(relation string, ps ...Predicate) *DQuery {
+	return dq.withRollup(&rollup{
+		name:       relation + "_count",
+		relation:   relation,
+		fn:         Count(),
+		predicates: ps,
+	})
+}
+
+// WithSum configures the query builder to sum the given field of the rows that are related
+// with the given relation to each row, without loading them. The sums are stored as float64
+// values in the Aggregates of the rows, under the name "<relation>_<field>_sum", and are 0
+// for rows without related rows. The predicates filter the summed rows.
+//
+//	users, err := client.Table("users").Query().
+//		WithSum("orders", "amount").
+//		All(ctx)
+//	users[0].Aggregates["orders_amount_sum"]
+func (dq *DQuery) WithSum(relation, field string, ps ...Predicate) *DQuery {
+	return dq.withRollup(&rollup{
+		name:       relation + "_" + field + "_sum",
+		relation:   relation,
+		field:      field,
+		fn:         Sum(field),
+		predicates: ps,
+	})
+}
+
+// withRollup adds the rollup to the query, or replaces the rollup with the same name.
+func (dq *DQuery) withRollup(ru *rollup) *DQuery {
+	for i := range dq.rollups {
+		if dq.rollups[i].name == ru.name {
+			dq.rollups[i] = ru
+			return dq
+		}
+	}
+	dq.rollups = append(dq.rollups, ru)
+	return dq
+}
+
+// loadRollups computes the aggregations of the related rows of the nodes,
+// with one grouped query for each aggregation.
+func (dq *DQuery) loadRollups(ctx context.Context, nodes []*Dynamic) error {
+	if len(dq.rollups) == 0 {
+		return nil
+	}
+	ids, nodeids := nodeIDs(nodes)
+	for _, ru := range dq.rollups {
+		values, err := ru.query(ctx, dq.table, ids)
+		if err != nil {
+			return err
+		}
+		for _, n := range nodes {
+			if n.Aggregates == nil {
+				n.Aggregates = make(map[string]ent.Value)
+			}
+			if ru.field != "" {
+				n.Aggregates[ru.name] = float64(0)
+			} else {
+				n.Aggregates[ru.name] = int64(0)
+			}
+		}
+		for id, v := range values {
+			for _, n := range nodeids[id] {
+				n.Aggregates[ru.name] = v
+			}
+		}
+	}
+	return nil
+}
+
+// query runs the grouped query of the aggregation, and returns its values by the
+// IDs of the rows. The related rows are selected from their table, and grouped by
+// the column that references the rows.
+//
+//	SELECT `comments`.`post_id`, COUNT(*) FROM `comments` WHERE `comments`.`post_id` IN (...) GROUP BY `comments`.`post_id`
+func (ru *rollup) query(ctx context.Context, t *Table, ids []driver.Value) (map[int]ent.Value, error) {
+	r, err := t.relation(ru.relation)
+	if err != nil {
+		return nil, err
+	}
+	related := t.relatedTable(r)
+	if ru.field != "" {
+		c, ok := related.Column(ru.field)
+		if !ok || !c.Type.Numeric() {
+			return nil, &ValidationError{Name: ru.field, err: fmt.Errorf("ent: invalid field %q for sum of relation %q", ru.field, ru.relation)}
+		}
+	}
+	var (
+		builder  = sql.Dialect(t.driver.Dialect())
+		to       = builder.Table(r.To)
+		selector = builder.Select().From(to)
+		key      string
+	)
+	switch {
+	case r.Rel == sqlgraph.M2M:
+		from, toColumn := r.Columns[0], r.Columns[1]
+		if r.Inverse {
+			from, toColumn = toColumn, from
+		}
+		join := builder.Table(r.Table)
+		selector.Join(join).On(join.C(toColumn), to.C(FieldID))
+		key = join.C(from)
+	case r.Rel == sqlgraph.M2O || (r.Rel == sqlgraph.O2O && r.Inverse):
+		// The table of the rows is aliased, as it
+		// may be the related table (e.g. a tree).
+		owner := builder.Table(t.Name).As(t.Name + "_owner")
+		selector.Join(owner).On(owner.C(r.Columns[0]), to.C(FieldID))
+		key = owner.C(FieldID)
+	default:
+		key = to.C(r.Columns[0])
+	}
+	selector.Where(sql.InValues(key, ids...))
+	for _, p := range ru.predicates {
+		p(selector)
+	}
+	selector.Select(key, ru.fn(selector)).GroupBy(key)
+	if err := selector.Err(); err != nil {
+		return nil, err
+	}
+	rows := &sql.Rows{}
+	query, args := selector.Query()
+	if err := t.driver.Query(ctx, query, args, rows); err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	values := make(map[int]ent.Value)
+	for rows.Next() {
+		var id int
+		if ru.field != "" {
+			var v sql.NullFloat64
+			if err := rows.Scan(&id, &v); err != nil {
+				return nil, err
+			}
+			values[id] = v.Float64
+		} else {
+			var v int64
+			if err := rows.Scan(&id, &v); err != nil {
+				return nil, err
+			}
+			values[id] = v
+		}
+	}
+	return values, rows.Err()
+}
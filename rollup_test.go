@@ -0,0 +1,78 @@
+package dent
+
+import (
+	"context"
+	"testing"
+
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+)
+
+func TestRollups(t *testing.T) {
+	ctx := context.Background()
+	client, nodes := openRelations(t)
+	orders := NewTable("orders")
+	orders.AddColumn(&schema.Column{Name: "user_id", Type: field.TypeInt})
+	orders.AddColumn(&schema.Column{Name: "amount", Type: field.TypeFloat64})
+	orders.AddColumn(&schema.Column{Name: "status", Type: field.TypeString})
+	if err := client.Schema.Create(ctx, orders); err != nil {
+		t.Fatalf("failed creating table: %v", err)
+	}
+	client.AddTable(orders)
+	client.AddRelation("users", HasMany("orders", "orders", "user_id"))
+	for _, o := range []struct {
+		user   string
+		amount float64
+		status string
+	}{
+		{"a8m", 10, "paid"}, {"a8m", 2.5, "paid"}, {"a8m", 100, "canceled"}, {"nati", 7, "paid"},
+	} {
+		client.Table("orders").Create().
+			SetValue("user_id", nodes[o.user].ID).
+			SetValue("amount", o.amount).
+			SetValue("status", o.status).
+			SaveX(ctx)
+	}
+
+	users := client.Table("users").Query().
+		WithCount("roles").
+		WithCount("orders", StringEQ("status", "paid")).
+		WithSum("orders", "amount", StringEQ("status", "paid")).
+		Order(Asc(FieldID)).
+		AllX(ctx)
+	for i, want := range []map[string]interface{}{
+		{"roles_count": int64(2), "orders_count": int64(2), "orders_amount_sum": 12.5},
+		{"roles_count": int64(1), "orders_count": int64(1), "orders_amount_sum": float64(7)},
+		// Rows without related rows.
+		{"roles_count": int64(0), "orders_count": int64(0), "orders_amount_sum": float64(0)},
+	} {
+		for k, v := range want {
+			if got := users[i].Aggregates[k]; got != v {
+				t.Errorf("unexpected %s of %v: %#v", k, users[i].Row["name"], got)
+			}
+		}
+	}
+	// A later count of the relation replaces the previous one.
+	a8m := client.Table("users").Query().
+		Where(ID(nodes["a8m"].ID)).
+		WithCount("orders", StringEQ("status", "paid")).
+		WithCount("orders").
+		OnlyX(ctx)
+	if got := a8m.Aggregates["orders_count"]; got != int64(3) {
+		t.Errorf("unexpected count of orders: %#v", got)
+	}
+	for _, p := range client.Table("permissions").Query().WithCount("role").AllX(ctx) {
+		if got := p.Aggregates["role_count"]; got != int64(1) {
+			t.Errorf("unexpected count of role of permission %v: %#v", p.ID, got)
+		}
+	}
+	for _, q := range []*DQuery{
+		client.Table("users").Query().WithSum("orders", "status"),
+		client.Table("users").Query().WithSum("orders", "nope"),
+		client.Table("users").Query().WithCount("groups"),
+	} {
+		if _, err := q.All(ctx); !IsValidationError(err) {
+			t.Errorf("expected validation error, got: %v", err)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package dent
+
+import (
+	"context"
+	"fmt"
+
+	"entgo.io/ent/dialect/sql"
+)
+
+// Rows is a cursor over the result of a query, that scans the rows one
+// by one while they are read from the database. Rows must be closed
+// after use. Edges requested with WithData are not loaded by Rows.
+//
+//	rows, err := client.Table("user").Query().Rows(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	defer rows.Close()
+//	for rows.Next() {
+//		fmt.Println(rows.Dynamic())
+//	}
+//	return rows.Err()
+type Rows struct {
+	query   *DQuery
+	table   *Table
+	rows    *sql.Rows
+	columns []string
+	node    *Dynamic
+	err     error
+}
+
+// Rows executes the query and returns a cursor over its rows.
+func (dq *DQuery) Rows(ctx context.Context) (*Rows, error) {
+	if err := dq.prepareQuery(ctx); err != nil {
+		return nil, err
+	}
+	query := *dq
+	if len(dq.fields) > 0 {
+		query.fields = []string{FieldID}
+		for _, f := range dq.fields {
+			if f != FieldID {
+				query.fields = append(query.fields, f)
+			}
+		}
+	}
+	rows, columns, err := query.sqlRows(ctx, query.sqlQuery(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &Rows{query: &query, table: dq.table, rows: rows, columns: columns}, nil
+}
+
+// Next prepares the next row for reading with the Dynamic method. It
+// returns false when there are no more rows, or if an error occurred.
+func (r *Rows) Next() bool {
+	if r.err != nil || !r.rows.Next() {
+		r.node = nil
+		return false
+	}
+	values, err := r.query.scanValues(r.columns)
+	if err != nil {
+		r.err = err
+		return false
+	}
+	if err := r.rows.Scan(values...); err != nil {
+		r.err = err
+		return false
+	}
+	node := r.table.newDynamic()
+	if err := node.assignValues(r.columns, values); err != nil {
+		r.err = err
+		return false
+	}
+	r.node = node
+	return true
+}
+
+// Dynamic returns the current row.
+func (r *Rows) Dynamic() *Dynamic {
+	return r.node
+}
+
+// Err returns the error, if any, that was encountered during iteration.
+func (r *Rows) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.rows.Err()
+}
+
+// Close closes the rows, releasing the underlying connection.
+func (r *Rows) Close() error {
+	return r.rows.Close()
+}
+
+// Iterate executes the query and calls fn for each row, while the rows are
+// read from the database. Iteration stops on the first error returned by fn.
+// Edges requested with WithData are not loaded, use Batches for that.
+func (dq *DQuery) Iterate(ctx context.Context, fn func(*Dynamic) error) error {
+	rows, err := dq.Rows(ctx)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		if err := fn(rows.Dynamic()); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Batches walks the result of the query in chunks of the given size ordered by
+// ID, and calls fn for each chunk. Every chunk is loaded by a separate query that
+// starts after the last ID of the previous one, so long running jobs do not keep
+// a single query open. The order, limit and offset steps of the query are ignored.
+func (dq *DQuery) Batches(ctx context.Context, size int, fn func([]*Dynamic) error) error {
+	if size <= 0 {
+		return &ValidationError{Name: "size", err: fmt.Errorf("ent: invalid batch size %d", size)}
+	}
+	var last *int
+	for {
+		query := *dq
+		query.predicates = append([]Predicate{}, dq.predicates...)
+		query.qualify = append([]Predicate{}, dq.qualify...)
+		if last != nil {
+			query.filter(IDGT(*last))
+		}
+		query.order = []OrderFunc{Asc(FieldID)}
+		query.limit, query.offset = &size, nil
+		nodes, err := query.All(ctx)
+		if err != nil {
+			return err
+		}
+		if len(nodes) == 0 {
+			return nil
+		}
+		if err := fn(nodes); err != nil {
+			return err
+		}
+		if len(nodes) < size {
+			return nil
+		}
+		last = &nodes[len(nodes)-1].ID
+	}
+}
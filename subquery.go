@@ -0,0 +1,126 @@
+package dent
+
+import (
+	"context"
+	"fmt"
+
+	"entgo.io/ent/dialect/sql"
+)
+
+// Subquery is a query that can be embedded in the predicates of another query.
+// It is implemented by DQuery and DynamicSelect.
+type Subquery interface {
+	subquery(context.Context) (*sql.Selector, error)
+}
+
+// subquery returns the selector of the query, to be used as a subquery. The query is
+// prepared on a copy, as the predicates that embed it may be evaluated more than once.
+func (dq *DQuery) subquery(ctx context.Context) (*sql.Selector, error) {
+	// The predicates of the subquery are checked against its own table when
+	// it is prepared, and not by the check of the query that embeds it.
+	ctx = context.WithValue(ctx, predicateCheckKey{}, nil)
+	dq = dq.Clone()
+	if err := dq.prepareQuery(ctx); err != nil {
+		return nil, err
+	}
+	return dq.sqlQuery(ctx), nil
+}
+
+// InQuery applies the IN predicate on the given field, with the values selected by the subquery.
+// The subquery must select exactly one field, for example:
+//
+//	client.Table("users").Query().
+//		Where(InQuery("id", client.Table("posts").Query().Where(IntGT("likes", 100)).Select("user_id")))
+func InQuery(field string, q Subquery) Predicate {
+	return Predicate(func(s *sql.Selector) {
+		sub, err := selectOne(s.Context(), q)
+		if err != nil {
+			addError(s, err)
+			return
+		}
+		s.Where(sql.In(s.C(field), sub))
+	})
+}
+
+// NotInQuery applies the NOT IN predicate on the given field, with the values selected by the subquery.
+func NotInQuery(field string, q Subquery) Predicate {
+	return Predicate(func(s *sql.Selector) {
+		sub, err := selectOne(s.Context(), q)
+		if err != nil {
+			addError(s, err)
+			return
+		}
+		s.Where(sql.NotIn(s.C(field), sub))
+	})
+}
+
+// ExistsQuery applies the EXISTS predicate with the given subquery. The optional conditions
+// correlate the subquery with the query, where the first table is the table of the query and
+// the second one is the table of the subquery. For example, users that have posts:
+//
+//	client.Table("users").Query().
+//		Where(ExistsQuery(client.Table("posts").Query(), On("id", "user_id")))
+//
+// Subqueries of the table of the query are correlated using an alias of their rows.
+func ExistsQuery(q Subquery, on ...JoinOn) Predicate {
+	return Predicate(func(s *sql.Selector) {
+		sub, err := correlate(s, q, on)
+		if err != nil {
+			addError(s, err)
+			return
+		}
+		s.Where(sql.Exists(sub))
+	})
+}
+
+// NotExistsQuery applies the NOT EXISTS predicate with the given subquery.
+// See ExistsQuery for the optional conditions.
+func NotExistsQuery(q Subquery, on ...JoinOn) Predicate {
+	return Predicate(func(s *sql.Selector) {
+		sub, err := correlate(s, q, on)
+		if err != nil {
+			addError(s, err)
+			return
+		}
+		s.Where(sql.NotExists(sub))
+	})
+}
+
+// selectOne returns the selector of the subquery, and checks that it selects one column.
+// Queries without selected fields select their ID.
+func selectOne(ctx context.Context, q Subquery) (*sql.Selector, error) {
+	if dq, ok := q.(*DQuery); ok && len(dq.fields) == 0 {
+		dq = dq.Clone()
+		dq.fields = []string{FieldID}
+		q = dq
+	}
+	sub, err := q.subquery(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if n := len(sub.SelectedColumns()); n != 1 {
+		return nil, fmt.Errorf("ent: subquery must select exactly 1 field, got %d", n)
+	}
+	return sub, nil
+}
+
+// correlate returns the selector of the subquery with the given conditions.
+func correlate(s *sql.Selector, q Subquery, on []JoinOn) (*sql.Selector, error) {
+	sub, err := q.subquery(s.Context())
+	if err != nil || len(on) == 0 {
+		return sub, err
+	}
+	joined := sub.Table()
+	if sub.TableName() == s.TableName() {
+		// The subquery selects from the table of the query, that is shadowed
+		// in its conditions. Therefore, its rows are selected with an alias.
+		builder := sql.Dialect(s.Dialect())
+		as := s.TableName() + "_correlated"
+		joined = builder.Table(as)
+		sub = builder.Select().From(sub.As(as))
+	}
+	for _, fn := range on {
+		sub.Where(fn(s.Table(), joined))
+	}
+	return sub, nil
+}
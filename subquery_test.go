@@ -0,0 +1,60 @@
+package dent
+
+import (
+	"context"
+	"testing"
+
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+)
+
+func TestSubquery(t *testing.T) {
+	ctx := context.Background()
+	users := NewTable("users")
+	users.AddColumn(&schema.Column{Name: "name", Type: field.TypeString})
+	posts := NewTable("posts")
+	posts.AddColumn(&schema.Column{Name: "user_id", Type: field.TypeInt})
+	posts.AddColumn(&schema.Column{Name: "likes", Type: field.TypeInt})
+	client := openTest(t, users, posts)
+	for _, name := range []string{"a", "b", "c"} {
+		client.Table("users").Create().SetValue("name", name).SaveX(ctx)
+	}
+	for _, p := range [][2]int{{1, 10}, {1, 1}, {2, 5}} {
+		client.Table("posts").Create().SetValue("user_id", p[0]).SetValue("likes", p[1]).SaveX(ctx)
+	}
+	popular := client.Table("posts").Query().Where(IntGTE("likes", 5)).Select("user_id")
+	query := client.Table("users").Query().Where(InQuery(FieldID, popular)).Order(Asc(FieldID))
+	// The subquery is evaluated by each execution of the query.
+	for i := 0; i < 2; i++ {
+		if ids := query.Clone().IDsX(ctx); !equalInts(ids, []int{1, 2}) {
+			t.Errorf("unexpected users with popular posts: %v", ids)
+		}
+	}
+	if n := query.Clone().CountX(ctx); n != 2 {
+		t.Errorf("unexpected count of users with popular posts: %d", n)
+	}
+	// The subquery is not modified by the queries that embed it.
+	if n := popular.CountX(ctx); n != 2 {
+		t.Errorf("unexpected count of popular posts: %d", n)
+	}
+	ids := client.Table("users").Query().
+		Where(NotExistsQuery(client.Table("posts").Query(), On(FieldID, "user_id"))).
+		IDsX(ctx)
+	if !equalInts(ids, []int{3}) {
+		t.Errorf("unexpected users without posts: %v", ids)
+	}
+	// Subqueries of the same table are correlated with their own rows.
+	ids = client.Table("posts").Query().
+		Where(ExistsQuery(client.Table("posts").Query().Where(IntGT("likes", 0)), On("user_id", "user_id"), func(t, joined *sql.SelectTable) *sql.Predicate {
+			return sql.ColumnsGT(joined.C("likes"), t.C("likes"))
+		})).
+		IDsX(ctx)
+	if !equalInts(ids, []int{2}) {
+		t.Errorf("unexpected posts with more liked posts of their users: %v", ids)
+	}
+	_, err := client.Table("users").Query().Where(InQuery(FieldID, client.Table("posts").Query().Select("user_id", "likes"))).IDs(ctx)
+	if err == nil {
+		t.Error("expected error of subquery with multiple fields")
+	}
+}
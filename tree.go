@@ -0,0 +1,313 @@
+package dent
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"entgo.io/ent"
+	"entgo.io/ent/dialect/sql"
+	"entgo.io/ent/dialect/sql/sqlgraph"
+)
+
+// Parent returns the relation of the rows of a self-referencing table to their parent
+// row, that is referenced by the given column. A table has at most one parent relation,
+// that is used by the hierarchical queries of the table (e.g. DQuery.Descendants), and
+// protects the table from cycles on updates of the parent column.
+//
+//	client.AddRelation("categories",
+//		Parent("parent", "parent_id"),
+//		HasMany("children", "categories", "parent_id"),
+//	)
+func Parent(name, column string) *Relation {
+	return &Relation{Name: name, Rel: sqlgraph.M2O, Inverse: true, Columns: []string{column}, parent: true}
+}
+
+// parentRelation returns the parent relation of the table, if it was registered.
+func (c *Table) parentRelation() (*Relation, bool) {
+	if c.client == nil {
+		return nil, false
+	}
+	for _, r := range c.client.rmap[c.Name] {
+		if r.parent {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// treeQuery is the eager-loading of the subtrees of the rows of a query.
+type treeQuery struct {
+	key      string
+	maxDepth int
+}
+
+// Ancestors filters the rows of the query to the ancestors of the row with the
+// given id, using the parent relation of the table (see Parent).
+//
+//	client.Table("categories").Query().
+//		Ancestors(id).
+//		All(ctx)
+func (dq *DQuery) Ancestors(id int) *DQuery {
+	t := dq.table
+	return dq.Where(func(s *sql.Selector) {
+		r, err := t.treeRelation()
+		if err != nil {
+			s.AddError(err)
+			return
+		}
+		idIn(func(b *sql.Builder) {
+			t.ancestors(b, r.Columns[0], id)
+		})(s)
+	})
+}
+
+// Descendants filters the rows of the query to the descendants of the row with the
+// given id, up to maxDepth levels below it (or all levels if maxDepth is 0), using
+// the parent relation of the table (see Parent).
+//
+//	client.Table("categories").Query().
+//		Descendants(id, 2).
+//		All(ctx)
+func (dq *DQuery) Descendants(id, maxDepth int) *DQuery {
+	t := dq.table
+	return dq.Where(func(s *sql.Selector) {
+		r, err := t.treeRelation()
+		if err != nil {
+			s.AddError(err)
+			return
+		}
+		idIn(func(b *sql.Builder) {
+			t.descendants(b, r.Columns[0], maxDepth, func(b *sql.Builder) {
+				b.Arg(id)
+			})
+		})(s)
+	})
+}
+
+// WithDescendants configures the query builder to eager-load the subtrees of the
+// rows, up to maxDepth levels below them (or all levels if maxDepth is 0). The
+// children of each row are stored in its Edges.ListMap under the given key.
+//
+//	roots, err := client.Table("categories").Query().
+//		Where(IntEQ("parent_id", 0)).
+//		WithDescendants("children", 0).
+//		All(ctx)
+func (dq *DQuery) WithDescendants(storeKey string, maxDepth int) *DQuery {
+	dq.tree = &treeQuery{key: storeKey, maxDepth: maxDepth}
+	return dq
+}
+
+// treeRelation returns the parent relation of the table, or an error if it is missing.
+func (c *Table) treeRelation() (*Relation, error) {
+	r, ok := c.parentRelation()
+	if !ok {
+		return nil, &ValidationError{Name: "parent", err: fmt.Errorf("ent: missing parent relation for table %q", c.Name)}
+	}
+	return r, nil
+}
+
+// idIn returns the predicate that checks that the ID is one of the IDs
+// that are selected by the query that is written by fn.
+func idIn(fn func(*sql.Builder)) Predicate {
+	return Predicate(func(s *sql.Selector) {
+		s.Where(sql.P(func(b *sql.Builder) {
+			b.WriteString(s.C(FieldID) + " IN ")
+			b.Nested(fn)
+		}))
+	})
+}
+
+// ancestors writes the recursive query of the IDs of the ancestors of the row.
+//
+//	WITH RECURSIVE `t_tree`(`id`) AS (
+//		SELECT `t`.`parent_id` FROM `t` WHERE `t`.`id` = ?
+//		UNION
+//		SELECT `t`.`parent_id` FROM `t` JOIN `t_tree` ON `t`.`id` = `t_tree`.`id`
+//	) SELECT `id` FROM `t_tree`
+//
+// Using UNION (and not UNION ALL) ends the recursion on cycles.
+func (c *Table) ancestors(b *sql.Builder, column string, id int) {
+	tree := c.Name + "_tree"
+	b.WriteString("WITH RECURSIVE ").Ident(tree).WriteByte('(').Ident(FieldID).WriteString(") AS ")
+	b.Nested(func(b *sql.Builder) {
+		b.WriteString("SELECT ").Ident(c.Name).WriteByte('.').Ident(column).
+			WriteString(" FROM ").Ident(c.Name).
+			WriteString(" WHERE ").Ident(c.Name).WriteByte('.').Ident(FieldID).WriteString(" = ").Arg(id)
+		b.WriteString(" UNION ")
+		b.WriteString("SELECT ").Ident(c.Name).WriteByte('.').Ident(column).
+			WriteString(" FROM ").Ident(c.Name).
+			WriteString(" JOIN ").Ident(tree).
+			WriteString(" ON ").Ident(c.Name).WriteByte('.').Ident(FieldID).WriteString(" = ").Ident(tree).WriteByte('.').Ident(FieldID)
+	})
+	b.WriteString(" SELECT ").Ident(FieldID).WriteString(" FROM ").Ident(tree)
+}
+
+// descendants writes the recursive query of the IDs of the descendants of the
+// rows whose IDs are written by roots, up to maxDepth levels below them.
+//
+//	WITH RECURSIVE `t_tree`(`id`, `depth`) AS (
+//		SELECT `t`.`id`, 1 FROM `t` WHERE `t`.`parent_id` IN (...)
+//		UNION ALL
+//		SELECT `t`.`id`, `t_tree`.`depth` + 1 FROM `t` JOIN `t_tree` ON `t`.`parent_id` = `t_tree`.`id` WHERE `t_tree`.`depth` < ?
+//	) SELECT `id` FROM `t_tree`
+//
+// Without a depth limit, the depth is omitted and UNION ends the recursion on cycles.
+func (c *Table) descendants(b *sql.Builder, column string, maxDepth int, roots func(*sql.Builder)) {
+	tree := c.Name + "_tree"
+	b.WriteString("WITH RECURSIVE ").Ident(tree).WriteByte('(').Ident(FieldID)
+	if maxDepth > 0 {
+		b.WriteString(", ").Ident("depth")
+	}
+	b.WriteString(") AS ")
+	b.Nested(func(b *sql.Builder) {
+		b.WriteString("SELECT ").Ident(c.Name).WriteByte('.').Ident(FieldID)
+		if maxDepth > 0 {
+			b.WriteString(", 1")
+		}
+		b.WriteString(" FROM ").Ident(c.Name).
+			WriteString(" WHERE ").Ident(c.Name).WriteByte('.').Ident(column).WriteString(" IN ")
+		b.Nested(roots)
+		if maxDepth > 0 {
+			b.WriteString(" UNION ALL ")
+		} else {
+			b.WriteString(" UNION ")
+		}
+		b.WriteString("SELECT ").Ident(c.Name).WriteByte('.').Ident(FieldID)
+		if maxDepth > 0 {
+			b.WriteString(", ").Ident(tree).WriteByte('.').Ident("depth").WriteString(" + 1")
+		}
+		b.WriteString(" FROM ").Ident(c.Name).
+			WriteString(" JOIN ").Ident(tree).
+			WriteString(" ON ").Ident(c.Name).WriteByte('.').Ident(column).WriteString(" = ").Ident(tree).WriteByte('.').Ident(FieldID)
+		if maxDepth > 0 {
+			b.WriteString(" WHERE ").Ident(tree).WriteByte('.').Ident("depth").WriteString(" < ").Arg(maxDepth)
+		}
+	})
+	b.WriteString(" SELECT ").Ident(FieldID).WriteString(" FROM ").Ident(tree)
+}
+
+// loadTree eager-loads the subtrees of the nodes, and stores the children
+// of each node in its Edges.ListMap.
+func (dq *DQuery) loadTree(ctx context.Context, nodes []*Dynamic) error {
+	r, err := dq.table.treeRelation()
+	if err != nil {
+		return err
+	}
+	column := r.Columns[0]
+	byID := make(map[int64]*Dynamic, len(nodes))
+	for _, n := range nodes {
+		byID[int64(n.ID)] = n
+	}
+	// The roots are loaded in chunks that respect the argument limit of the
+	// database, where one argument is taken by the depth of the subtrees.
+	size := dq.table.maxArgs() - 1
+	if size < 1 {
+		size = 1
+	}
+	var children []*Dynamic
+	for i := 0; i < len(nodes); i += size {
+		chunk := nodes[i:]
+		if len(chunk) > size {
+			chunk = chunk[:size]
+		}
+		args := make([]interface{}, len(chunk))
+		for j, n := range chunk {
+			args[j] = n.ID
+		}
+		descendants, err := dq.table.Query().
+			Where(idIn(func(b *sql.Builder) {
+				dq.table.descendants(b, column, dq.tree.maxDepth, func(b *sql.Builder) {
+					b.Args(args...)
+				})
+			})).
+			All(ctx)
+		if err != nil {
+			return err
+		}
+		for _, n := range descendants {
+			// The roots are excluded from the descendants, in case they are part of a
+			// cycle, and the descendants that are shared by chunks are loaded once.
+			if _, ok := byID[int64(n.ID)]; ok {
+				continue
+			}
+			byID[int64(n.ID)] = n
+			children = append(children, n)
+		}
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].ID < children[j].ID })
+	for _, n := range children {
+		fk, ok := n.Row[column].(int64)
+		if !ok {
+			return fmt.Errorf("ent: unexpected parent %v of node %v", n.Row[column], n.ID)
+		}
+		if parent, ok := byID[fk]; ok {
+			parent.Edges.ListMap[dq.tree.key] = append(parent.Edges.ListMap[dq.tree.key], n)
+		}
+	}
+	return nil
+}
+
+// checkTree returns an error if the update of the parent column of the rows
+// creates a cycle in the tree of the table, where the new parent of the rows
+// is one of the rows or their descendants. The parent column is checked only if
+// it was set on the mutation, and not copied from the row of its entity.
+func (m *DMutation) checkTree(ctx context.Context) error {
+	r, ok := m.table.parentRelation()
+	if !ok {
+		return nil
+	}
+	column := r.Columns[0]
+	v, ok := m.Value(column)
+	if !ok || m.entity != nil && isCopied(v, m.entity.Row[column]) {
+		return nil
+	}
+	if p, ok := v.(*interface{}); ok {
+		v = *p
+	}
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || !isInt(rv.Kind()) {
+		// NULL values and SQL expressions.
+		return nil
+	}
+	parent := rv.Convert(reflect.TypeOf(int64(0))).Int()
+	if parent == 0 {
+		return nil
+	}
+	t := m.table
+	// roots writes the IDs of the updated rows.
+	roots := func(b *sql.Builder) {
+		if id, ok := m.ID(); ok {
+			b.Arg(id)
+			return
+		}
+		s := sql.Dialect(b.Dialect()).Select(FieldID).From(sql.Table(t.Name))
+		for _, p := range m.predicates {
+			p(s)
+		}
+		b.Join(s)
+	}
+	cycle, err := t.Query().
+		Where(ID(int(parent)), Or(idIn(roots), idIn(func(b *sql.Builder) {
+			t.descendants(b, column, 0, roots)
+		}))).
+		Exist(ctx)
+	if err != nil {
+		return err
+	}
+	if cycle {
+		return &ValidationError{Name: column, err: fmt.Errorf("ent: parent %d of table %q creates a cycle", parent, t.Name)}
+	}
+	return nil
+}
+
+// isCopied reports if the value of the mutation is the value that was copied from
+// the row of its entity. Values of SetValue are stored as pointers, and never match.
+func isCopied(v, entity ent.Value) bool {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || !rv.Type().Comparable() {
+		return false
+	}
+	return v == entity
+}
@@ -0,0 +1,153 @@
+package dent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+)
+
+func TestTree(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("categories")
+	table.AddColumn(&schema.Column{Name: "name", Type: field.TypeString})
+	table.AddColumn(&schema.Column{Name: "parent_id", Type: field.TypeInt, Nullable: true})
+	client := openTest(t, table)
+	client.AddRelation("categories",
+		Parent("parent", "parent_id"),
+		HasMany("children", "categories", "parent_id"),
+	)
+	categories := client.Table("categories")
+	// root
+	// ├── a
+	// │   └── a1
+	// │       └── a11
+	// └── b
+	ids := make(map[string]int)
+	for _, c := range [][2]string{{"root", ""}, {"a", "root"}, {"b", "root"}, {"a1", "a"}, {"a11", "a1"}} {
+		create := categories.Create().SetValue("name", c[0])
+		if c[1] != "" {
+			create.SetValue("parent_id", ids[c[1]])
+		}
+		ids[c[0]] = create.SaveX(ctx).ID
+	}
+
+	for _, tt := range []struct {
+		name string
+		q    *DQuery
+		want []string
+	}{
+		{name: "ancestors", q: categories.Query().Ancestors(ids["a11"]), want: []string{"root", "a", "a1"}},
+		{name: "ancestors of root", q: categories.Query().Ancestors(ids["root"]), want: nil},
+		{name: "descendants", q: categories.Query().Descendants(ids["root"], 0), want: []string{"a", "b", "a1", "a11"}},
+		{name: "descendants by depth", q: categories.Query().Descendants(ids["root"], 2), want: []string{"a", "b", "a1"}},
+		{name: "filtered descendants", q: categories.Query().Descendants(ids["a"], 0).Where(StringNEQ("name", "a1")), want: []string{"a11"}},
+	} {
+		if got := names(t, tt.q.Order(Asc(FieldID))); !equalStrings(got, tt.want) {
+			t.Errorf("%s: unexpected rows: %v", tt.name, got)
+		}
+	}
+
+	root := categories.Query().Where(ID(ids["root"])).WithDescendants("children", 0).OnlyX(ctx)
+	var tree func(n *Dynamic) string
+	tree = func(n *Dynamic) string {
+		children := n.Edges.List("children")
+		if len(children) == 0 {
+			return n.Row["name"].(string)
+		}
+		s := make([]string, len(children))
+		for i, c := range children {
+			s[i] = tree(c)
+		}
+		return fmt.Sprintf("%s(%s)", n.Row["name"], strings.Join(s, " "))
+	}
+	if got := tree(root); got != "root(a(a1(a11)) b)" {
+		t.Errorf("unexpected tree: %s", got)
+	}
+
+	// Updates of the parent are checked for cycles.
+	for _, name := range []string{"a", "a1", "a11"} {
+		err := categories.UpdateOneID(ids["a"]).SetValue("parent_id", ids[name]).Exec(ctx)
+		if !IsValidationError(err) {
+			t.Errorf("expected validation error of moving a under %s, got: %v", name, err)
+		}
+	}
+	err := categories.Update().Where(StringEQ("name", "root")).SetValue("parent_id", ids["b"]).Exec(ctx)
+	if !IsValidationError(err) {
+		t.Errorf("expected validation error of moving root under b, got: %v", err)
+	}
+	categories.UpdateOneID(ids["a1"]).SetValue("parent_id", ids["b"]).ExecX(ctx)
+	if got := names(t, categories.Query().Ancestors(ids["a11"]).Order(Asc(FieldID))); !equalStrings(got, []string{"root", "b", "a1"}) {
+		t.Errorf("unexpected ancestors after move: %v", got)
+	}
+
+	// The parent that is copied from the entity is not checked.
+	var queries []string
+	client.log = func(v ...interface{}) {
+		queries = append(queries, fmt.Sprint(v...))
+	}
+	a11 := client.Debug().Table("categories").Query().Where(ID(ids["a11"])).OnlyX(ctx)
+	queries = nil
+	a11.Update().SetValue("name", "a2").ExecX(ctx)
+	for _, q := range queries {
+		if strings.Contains(q, "WITH RECURSIVE") {
+			t.Errorf("unexpected cycle check: %s", q)
+		}
+	}
+	if err := a11.Update().SetValue("parent_id", ids["a11"]).Exec(ctx); !IsValidationError(err) {
+		t.Errorf("expected validation error of setting the parent of an entity, got: %v", err)
+	}
+	if _, err := client.Table("categories").Query().Where(StringEQ("name", "a2")).Only(ctx); err != nil {
+		t.Errorf("failed querying updated row: %v", err)
+	}
+}
+
+func TestTreeChunks(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("categories")
+	table.AddColumn(&schema.Column{Name: "name", Type: field.TypeString})
+	table.AddColumn(&schema.Column{Name: "parent_id", Type: field.TypeInt, Nullable: true})
+	client := openTest(t, table)
+	client.AddRelation("categories", Parent("parent", "parent_id"))
+	categories := client.Table("categories")
+	for i := 0; i < 5; i++ {
+		root := categories.Create().SetValue("name", fmt.Sprint("r", i)).SaveX(ctx)
+		child := categories.Create().SetValue("name", fmt.Sprint("c", i)).SetValue("parent_id", root.ID).SaveX(ctx)
+		categories.Create().SetValue("name", fmt.Sprint("g", i)).SetValue("parent_id", child.ID).SaveX(ctx)
+	}
+	var queries []string
+	client.log = func(v ...interface{}) {
+		queries = append(queries, fmt.Sprint(v...))
+	}
+	// Two roots and the depth are bound in each query.
+	client.argLimit = 3
+	roots := client.Debug().Table("categories").Query().
+		Where(Field("parent_id").IsNull()).
+		WithDescendants("children", 0).
+		Order(Asc(FieldID)).
+		AllX(ctx)
+	if len(roots) != 5 {
+		t.Fatalf("unexpected roots: %v", roots)
+	}
+	for i, r := range roots {
+		children := r.Edges.List("children")
+		if len(children) != 1 || children[0].Row["name"] != fmt.Sprint("c", i) {
+			t.Fatalf("unexpected children of %v: %v", r.Row["name"], children)
+		}
+		if grand := children[0].Edges.List("children"); len(grand) != 1 || grand[0].Row["name"] != fmt.Sprint("g", i) {
+			t.Errorf("unexpected children of %v: %v", children[0].Row["name"], grand)
+		}
+	}
+	var n int
+	for _, q := range queries {
+		if strings.Contains(q, "WITH RECURSIVE") {
+			n++
+		}
+	}
+	if n != 3 {
+		t.Errorf("expected 3 queries of descendants, got %d: %v", n, queries)
+	}
+}
@@ -140,8 +140,13 @@ func (tx *Tx) OnRollback(f RollbackHook) {
 // Client returns a Client that binds to current transaction.
 func (tx *Tx) Client() *Client {
 	tx.clientOnce.Do(func() {
-		tx.client = &Client{config: tx.config}
-		tx.client.init()
+		client := &Client{config: tx.config}
+		if tx.client != nil {
+			client.tmap = tx.client.tmap
+			client.rmap = tx.client.rmap
+		}
+		client.init()
+		tx.client = client
 	})
 	return tx.client
 }
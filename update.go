@@ -2,8 +2,10 @@ package dent
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 
 	"entgo.io/ent/dialect/sql"
 	"entgo.io/ent/dialect/sql/sqlgraph"
@@ -27,9 +29,32 @@ func (du *DUpdate) SetValue(name string, val interface{}) *DUpdate {
 	return du
 }
 
-// AddCreatorID adds i to the "creator_id" field.
-func (du *DUpdate) AddValue(name string, i int) *DUpdate {
-	du.mutation.AddValue(name, i)
+// AddValue adds v to the numeric field, with an atomic `SET name = name + v`.
+func (du *DUpdate) AddValue(name string, v interface{}) *DUpdate {
+	du.mutation.AddValue(name, v)
+	return du
+}
+
+// SetExpr sets the field to the result of the given SQL expression. For example:
+//
+//	SetExpr("price", sql.Expr("price * 1.1"))
+//	SetExpr("score", sql.ExprFunc(func(b *sql.Builder) {
+//		b.WriteString("GREATEST(").Ident("score").Comma().Arg(10).WriteString(")")
+//	}))
+func (du *DUpdate) SetExpr(name string, expr sql.Querier) *DUpdate {
+	du.mutation.SetExpr(name, expr)
+	return du
+}
+
+// SetJSONPath sets the value at the path of the JSON field, without replacing the rest of the document.
+func (du *DUpdate) SetJSONPath(name, path string, v interface{}) *DUpdate {
+	du.mutation.SetJSONPath(name, path, v)
+	return du
+}
+
+// AppendJSON appends the values to the array at the path of the JSON field.
+func (du *DUpdate) AppendJSON(name, path string, values ...interface{}) *DUpdate {
+	du.mutation.AppendJSON(name, path, values...)
 	return du
 }
 
@@ -49,6 +74,12 @@ func (du *DUpdate) Save(ctx context.Context) (int, error) {
 	if err := du.defaults(); err != nil {
 		return 0, err
 	}
+	if err := du.check(); err != nil {
+		return 0, err
+	}
+	if err := du.mutation.checkTree(ctx); err != nil {
+		return 0, err
+	}
 	return du.sqlSave(ctx)
 }
 
@@ -79,6 +110,11 @@ func (du *DUpdate) defaults() error {
 	return nil
 }
 
+// check runs all checks and user-defined validators on the builder.
+func (du *DUpdate) check() error {
+	return du.mutation.check()
+}
+
 func (du *DUpdate) sqlSave(ctx context.Context) (n int, err error) {
 	_spec := &sqlgraph.UpdateSpec{
 		Node: &sqlgraph.NodeSpec{
@@ -100,11 +136,26 @@ func (du *DUpdate) sqlSave(ctx context.Context) (n int, err error) {
 	for k, v := range du.mutation.data {
 		col, _ := du.mutation.table.Column(k)
 		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
+			Type:   valueType(col.Type, v),
+			Value:  v,
+			Column: k,
+		})
+	}
+	for k, v := range du.mutation.added {
+		col, _ := du.mutation.table.Column(k)
+		_spec.Fields.Add = append(_spec.Fields.Add, &sqlgraph.FieldSpec{
 			Type:   col.Type,
 			Value:  v,
 			Column: k,
 		})
 	}
+	for k := range du.mutation.clearedFields {
+		col, _ := du.mutation.table.Column(k)
+		_spec.Fields.Clear = append(_spec.Fields.Clear, &sqlgraph.FieldSpec{
+			Type:   col.Type,
+			Column: k,
+		})
+	}
 	if n, err = sqlgraph.UpdateNodes(ctx, du.mutation.table.driver, _spec); err != nil {
 		if _, ok := err.(*sqlgraph.NotFoundError); ok {
 			err = &NotFoundError{du.mutation.table.Name}
@@ -128,9 +179,32 @@ func (duo *DUpdateOne) SetValue(name string, val interface{}) *DUpdateOne {
 	return duo
 }
 
-// AddCreatorID adds i to the "creator_id" field.
-func (duo *DUpdateOne) AddValue(name string, i int) *DUpdateOne {
-	duo.mutation.AddValue(name, i)
+// AddValue adds v to the numeric field, with an atomic `SET name = name + v`.
+func (duo *DUpdateOne) AddValue(name string, v interface{}) *DUpdateOne {
+	duo.mutation.AddValue(name, v)
+	return duo
+}
+
+// SetExpr sets the field to the result of the given SQL expression. For example:
+//
+//	SetExpr("price", sql.Expr("price * 1.1"))
+//	SetExpr("score", sql.ExprFunc(func(b *sql.Builder) {
+//		b.WriteString("GREATEST(").Ident("score").Comma().Arg(10).WriteString(")")
+//	}))
+func (duo *DUpdateOne) SetExpr(name string, expr sql.Querier) *DUpdateOne {
+	duo.mutation.SetExpr(name, expr)
+	return duo
+}
+
+// SetJSONPath sets the value at the path of the JSON field, without replacing the rest of the document.
+func (duo *DUpdateOne) SetJSONPath(name, path string, v interface{}) *DUpdateOne {
+	duo.mutation.SetJSONPath(name, path, v)
+	return duo
+}
+
+// AppendJSON appends the values to the array at the path of the JSON field.
+func (duo *DUpdateOne) AppendJSON(name, path string, values ...interface{}) *DUpdateOne {
+	duo.mutation.AppendJSON(name, path, values...)
 	return duo
 }
 
@@ -157,7 +231,12 @@ func (duo *DUpdateOne) Save(ctx context.Context) (*Dynamic, error) {
 	if err := duo.defaults(); err != nil {
 		return nil, err
 	}
-
+	if err := duo.check(); err != nil {
+		return nil, err
+	}
+	if err := duo.mutation.checkTree(ctx); err != nil {
+		return nil, err
+	}
 	return duo.sqlSave(ctx)
 }
 
@@ -188,6 +267,11 @@ func (duo *DUpdateOne) defaults() error {
 	return nil
 }
 
+// check runs all checks and user-defined validators on the builder.
+func (duo *DUpdateOne) check() error {
+	return duo.mutation.check()
+}
+
 func (duo *DUpdateOne) sqlSave(ctx context.Context) (_node *Dynamic, err error) {
 	_spec := &sqlgraph.UpdateSpec{
 		Node: &sqlgraph.NodeSpec{
@@ -227,11 +311,26 @@ func (duo *DUpdateOne) sqlSave(ctx context.Context) (_node *Dynamic, err error)
 	for k, v := range duo.mutation.data {
 		col, _ := duo.mutation.table.Column(k)
 		_spec.Fields.Set = append(_spec.Fields.Set, &sqlgraph.FieldSpec{
+			Type:   valueType(col.Type, v),
+			Value:  v,
+			Column: k,
+		})
+	}
+	for k, v := range duo.mutation.added {
+		col, _ := duo.mutation.table.Column(k)
+		_spec.Fields.Add = append(_spec.Fields.Add, &sqlgraph.FieldSpec{
 			Type:   col.Type,
 			Value:  v,
 			Column: k,
 		})
 	}
+	for k := range duo.mutation.clearedFields {
+		col, _ := duo.mutation.table.Column(k)
+		_spec.Fields.Clear = append(_spec.Fields.Clear, &sqlgraph.FieldSpec{
+			Type:   col.Type,
+			Column: k,
+		})
+	}
 
 	_node = &Dynamic{table: duo.mutation.table}
 	_node.ID = id
@@ -248,3 +347,119 @@ func (duo *DUpdateOne) sqlSave(ctx context.Context) (_node *Dynamic, err error)
 	}
 	return _node, nil
 }
+
+// UpdateBulk updates the rows with the given IDs to their own column values, and
+// returns the number of affected rows. Rows are updated in batches, each with a
+// single statement that uses a CASE expression per column, inside one transaction.
+// Note that MySQL reports only the rows whose values were actually changed.
+//
+//	n, err := client.Table("user").UpdateBulk(ctx, map[int]map[string]interface{}{
+//		1: {"score": 10},
+//		2: {"score": 20, "rank": 1},
+//	})
+func (c *Table) UpdateBulk(ctx context.Context, rows map[int]map[string]interface{}) (int, error) {
+	ids := make([]int, 0, len(rows))
+	for id, values := range rows {
+		for column, v := range values {
+			col, ok := c.Column(column)
+			if column == FieldID || !ok {
+				return 0, &ValidationError{Name: column, err: fmt.Errorf("ent: invalid field %q for bulk update", column)}
+			}
+			if _, err := columnValue(valueType(col.Type, v), v); err != nil {
+				return 0, &ValidationError{Name: column, err: fmt.Errorf("ent: encoding value of field %q: %w", column, err)}
+			}
+		}
+		if len(values) > 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	var (
+		affected int
+		limit    = c.maxArgs()
+	)
+	err := c.withTx(ctx, func(t *Table) error {
+		for len(ids) > 0 {
+			// Each row takes its ID in the WHERE clause, and an
+			// ID and a value in the CASE expression of a column.
+			n, args := 0, 0
+			for n < len(ids) {
+				size := 1 + 2*len(rows[ids[n]])
+				if n > 0 && args+size > limit {
+					break
+				}
+				args += size
+				n++
+			}
+			res, err := t.updateBatch(ctx, ids[:n], rows)
+			if err != nil {
+				return err
+			}
+			affected += res
+			ids = ids[n:]
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return affected, nil
+}
+
+// updateBatch executes the update statement of the given rows.
+func (c *Table) updateBatch(ctx context.Context, ids []int, rows map[int]map[string]interface{}) (int, error) {
+	var columns []string
+	for _, id := range ids {
+		for column := range rows[id] {
+			columns = appendMissing(columns, column)
+		}
+	}
+	sort.Strings(columns)
+	update := sql.Dialect(c.driver.Dialect()).Update(c.Name)
+	for _, column := range columns {
+		column := column
+		col, _ := c.Column(column)
+		update.Set(column, sql.ExprFunc(func(b *sql.Builder) {
+			b.WriteString("CASE ").Ident(FieldID)
+			for _, id := range ids {
+				if v, ok := rows[id][column]; ok {
+					// Values were validated by UpdateBulk.
+					v, _ = columnValue(valueType(col.Type, v), v)
+					b.WriteString(" WHEN ").Arg(id).WriteString(" THEN ").Arg(v)
+				}
+			}
+			b.WriteString(" ELSE ").Ident(column).WriteString(" END")
+		}))
+	}
+	query, args := update.Where(sql.InInts(FieldID, ids...)).Query()
+	var res sql.Result
+	if err := c.driver.Exec(ctx, query, args, &res); err != nil {
+		if sqlgraph.IsConstraintError(err) {
+			err = &ConstraintError{msg: err.Error(), wrap: err}
+		}
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+// valueType returns the type of the field spec of the value. SQL expressions
+// on JSON columns are set as is, instead of being marshaled to JSON.
+func valueType(typ field.Type, v interface{}) field.Type {
+	if _, ok := v.(sql.Querier); ok && typ == field.TypeJSON {
+		return field.TypeOther
+	}
+	return typ
+}
+
+// columnValue returns the value of a column of the given type in an SQL statement.
+// JSON values are marshaled, as done by sqlgraph for the field specs of mutations.
+func columnValue(typ field.Type, v interface{}) (interface{}, error) {
+	if typ != field.TypeJSON {
+		return v, nil
+	}
+	return json.Marshal(v)
+}
@@ -0,0 +1,85 @@
+package dent
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+)
+
+func TestUpdateBulk(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("scores")
+	table.AddColumn(&schema.Column{Name: "name", Type: field.TypeString})
+	table.AddColumn(&schema.Column{Name: "score", Type: field.TypeInt})
+	table.AddColumn(&schema.Column{Name: "meta", Type: field.TypeJSON, Nullable: true})
+	client := openTest(t, table)
+	scores := client.Table("scores")
+	// Each row takes 5 arguments, and the rows are split into
+	// multiple statements by the argument limit of SQLite.
+	const n = 500
+	builders := make([]*DCreate, n)
+	for i := range builders {
+		builders[i] = scores.Create().SetValue("name", fmt.Sprint(i)).SetValue("score", 0)
+	}
+	nodes := scores.CreateBulk(builders...).SaveX(ctx)
+	rows := make(map[int]map[string]interface{}, n)
+	for i, node := range nodes {
+		rows[node.ID] = map[string]interface{}{"name": fmt.Sprint("n", i), "score": i}
+	}
+	// JSON values are encoded as in other updates.
+	rows[nodes[0].ID]["meta"] = []int{1, 2}
+	affected, err := scores.UpdateBulk(ctx, rows)
+	if err != nil {
+		t.Fatalf("failed updating rows: %v", err)
+	}
+	if affected != n {
+		t.Errorf("unexpected number of affected rows: %d", affected)
+	}
+	for i, node := range scores.Query().Order(Asc(FieldID)).AllX(ctx) {
+		if node.Row["name"] != fmt.Sprint("n", i) || node.Row["score"] != int64(i) {
+			t.Fatalf("unexpected row %d: %v", i, node.Row)
+		}
+	}
+	if meta := scores.Query().Where(ID(nodes[0].ID)).OnlyX(ctx).Row["meta"]; meta != "[1,2]" {
+		t.Errorf("unexpected json value: %#v", meta)
+	}
+	_, err = scores.UpdateBulk(ctx, map[int]map[string]interface{}{nodes[0].ID: {"nope": 1}})
+	if !IsValidationError(err) {
+		t.Errorf("expected validation error of unknown column, got: %v", err)
+	}
+}
+
+func TestUpdateAddValue(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("scores")
+	table.AddColumn(&schema.Column{Name: "name", Type: field.TypeString})
+	table.AddColumn(&schema.Column{Name: "score", Type: field.TypeInt})
+	table.AddColumn(&schema.Column{Name: "rate", Type: field.TypeFloat64})
+	client := openTest(t, table)
+	scores := client.Table("scores")
+	node := scores.Create().SetValue("name", "a").SetValue("score", 1).SetValue("rate", 0.5).SaveX(ctx)
+	node = node.Update().AddValue("score", 2).AddValue("score", int8(3)).AddValue("rate", 1).SaveX(ctx)
+	updated := scores.Query().Where(ID(node.ID)).OnlyX(ctx)
+	if updated.Row["score"] != int64(6) || updated.Row["rate"] != 1.5 {
+		t.Errorf("unexpected row: %v", updated.Row)
+	}
+	for _, u := range []*DUpdate{
+		scores.Update().AddValue("score", 1.5),
+		scores.Update().AddValue("name", 1),
+		scores.Update().AddValue("nope", 1),
+		scores.Update().AddValue("score", "1"),
+	} {
+		if _, err := u.Save(ctx); !IsValidationError(err) {
+			t.Errorf("expected validation error, got: %v", err)
+		}
+	}
+	if _, err := node.Update().AddValue("score", 1.5).Save(ctx); !IsValidationError(err) {
+		t.Errorf("expected validation error, got: %v", err)
+	}
+	if score := scores.Query().Where(ID(node.ID)).OnlyX(ctx).Row["score"]; score != int64(6) {
+		t.Errorf("unexpected score after failed updates: %v", score)
+	}
+}
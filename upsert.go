@@ -0,0 +1,298 @@
+package dent
+
+import (
+	"context"
+	stdsql "database/sql"
+	"errors"
+	"fmt"
+
+	"entgo.io/ent/dialect/sql"
+)
+
+// OnConflict allows configuring the `ON CONFLICT` / `ON DUPLICATE KEY` clause
+// of the `INSERT` statement. For example:
+//
+//	client.Table("user").Create().
+//		SetValue("name", "a8m").
+//		OnConflict(
+//			// Update the row with the new values
+//			// that were proposed for insertion.
+//			sql.ResolveWithNewValues(),
+//		).
+//		// Override some of the fields with custom
+//		// update values.
+//		Update(func(u *DUpsert) {
+//			u.SetValue("age", 30)
+//		}).
+//		Exec(ctx)
+func (dc *DCreate) OnConflict(opts ...sql.ConflictOption) *DUpsertOne {
+	dc.conflict = opts
+	return &DUpsertOne{create: dc}
+}
+
+// OnConflictColumns calls `OnConflict` and configures the columns
+// as conflict target. Using this option is equivalent to using:
+//
+//	client.Table("user").Create().
+//		OnConflict(sql.ConflictColumns(columns...)).
+//		Exec(ctx)
+func (dc *DCreate) OnConflictColumns(columns ...string) *DUpsertOne {
+	dc.conflict = append(dc.conflict, sql.ConflictColumns(columns...))
+	return &DUpsertOne{create: dc, columns: columns}
+}
+
+// DUpsert is the "OnConflict" setter of the update clause.
+type DUpsert struct {
+	*sql.UpdateSet
+}
+
+// SetValue sets the value of the given column on conflict.
+func (u *DUpsert) SetValue(column string, v interface{}) *DUpsert {
+	u.Set(column, v)
+	return u
+}
+
+// UpdateValue sets the given column to the value that was provided on create.
+func (u *DUpsert) UpdateValue(column string) *DUpsert {
+	u.SetExcluded(column)
+	return u
+}
+
+// AddValue adds v to the value of the given column on conflict.
+func (u *DUpsert) AddValue(column string, v interface{}) *DUpsert {
+	u.Add(column, v)
+	return u
+}
+
+// ClearValue sets the given column to NULL on conflict.
+func (u *DUpsert) ClearValue(column string) *DUpsert {
+	u.SetNull(column)
+	return u
+}
+
+// DUpsertOne is the builder for "upsert"-ing one Dynamic entity.
+type DUpsertOne struct {
+	create *DCreate
+	// conflict target, if it was set by OnConflictColumns.
+	columns []string
+}
+
+// UpdateNewValues updates the mutable fields using the new values that were set on create,
+// except the ID field.
+func (u *DUpsertOne) UpdateNewValues() *DUpsertOne {
+	u.create.conflict = append(u.create.conflict, sql.ResolveWithNewValues())
+	u.create.conflict = append(u.create.conflict, sql.ResolveWith(func(s *sql.UpdateSet) {
+		if _, exists := u.create.mutation.ID(); exists {
+			s.SetIgnore(FieldID)
+		}
+	}))
+	return u
+}
+
+// Ignore sets each column to itself in case of conflict, so the existing row
+// is kept as is, and its ID is reported.
+func (u *DUpsertOne) Ignore() *DUpsertOne {
+	u.create.conflict = append(u.create.conflict, sql.ResolveWithIgnore())
+	return u
+}
+
+// DoNothing configures the conflict_action to `DO NOTHING`.
+// Supported only by SQLite and PostgreSQL.
+func (u *DUpsertOne) DoNothing() *DUpsertOne {
+	u.create.conflict = append(u.create.conflict, sql.DoNothing())
+	return u
+}
+
+// Update allows overriding fields `UPDATE` values. See the DCreate.OnConflict
+// documentation for more info.
+func (u *DUpsertOne) Update(set func(*DUpsert)) *DUpsertOne {
+	u.create.conflict = append(u.create.conflict, sql.ResolveWith(func(update *sql.UpdateSet) {
+		set(&DUpsert{UpdateSet: update})
+	}))
+	return u
+}
+
+// SetValue sets the value of the given column on conflict.
+func (u *DUpsertOne) SetValue(column string, v interface{}) *DUpsertOne {
+	return u.Update(func(s *DUpsert) {
+		s.SetValue(column, v)
+	})
+}
+
+// UpdateValue sets the given column to the value that was provided on create.
+func (u *DUpsertOne) UpdateValue(column string) *DUpsertOne {
+	return u.Update(func(s *DUpsert) {
+		s.UpdateValue(column)
+	})
+}
+
+// AddValue adds v to the value of the given column on conflict.
+func (u *DUpsertOne) AddValue(column string, v interface{}) *DUpsertOne {
+	return u.Update(func(s *DUpsert) {
+		s.AddValue(column, v)
+	})
+}
+
+// ClearValue sets the given column to NULL on conflict.
+func (u *DUpsertOne) ClearValue(column string) *DUpsertOne {
+	return u.Update(func(s *DUpsert) {
+		s.ClearValue(column)
+	})
+}
+
+// Exec executes the query.
+func (u *DUpsertOne) Exec(ctx context.Context) error {
+	if len(u.create.conflict) == 0 {
+		return errors.New("ent: missing options for DCreate.OnConflict")
+	}
+	return u.create.Exec(ctx)
+}
+
+// ExecX is like Exec, but panics if an error occurs.
+func (u *DUpsertOne) ExecX(ctx context.Context) {
+	if err := u.Exec(ctx); err != nil {
+		panic(err)
+	}
+}
+
+// ID executes the UPSERT query and returns the inserted/updated ID. If the insert
+// was skipped by DoNothing, the ID of the existing row is looked up by the columns
+// given to OnConflictColumns.
+func (u *DUpsertOne) ID(ctx context.Context) (id int, err error) {
+	if len(u.create.conflict) == 0 {
+		return id, errors.New("ent: missing options for DCreate.OnConflict")
+	}
+	node, err := u.create.Save(ctx)
+	if errors.Is(err, stdsql.ErrNoRows) && len(u.columns) > 0 {
+		return u.existingID(ctx)
+	}
+	if err != nil {
+		return id, err
+	}
+	return node.ID, nil
+}
+
+// IDX is like ID, but panics if an error occurs.
+func (u *DUpsertOne) IDX(ctx context.Context) int {
+	id, err := u.ID(ctx)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// existingID returns the ID of the row that conflicts with the created values.
+func (u *DUpsertOne) existingID(ctx context.Context) (int, error) {
+	m := u.create.mutation
+	ps := make([]Predicate, 0, len(u.columns))
+	for _, c := range u.columns {
+		v, ok := m.Value(c)
+		if !ok || unset(v) {
+			return 0, fmt.Errorf("ent: missing value for conflict column %q", c)
+		}
+		column, value := c, v
+		ps = append(ps, func(s *sql.Selector) {
+			s.Where(sql.EQ(s.C(column), value))
+		})
+	}
+	return m.table.Query().Where(ps...).OnlyID(ctx)
+}
+
+// OnConflict allows configuring the `ON CONFLICT` / `ON DUPLICATE KEY` clause
+// of the `INSERT` statement. See DCreate.OnConflict for more info.
+func (dcb *DCreateBulk) OnConflict(opts ...sql.ConflictOption) *DUpsertBulk {
+	dcb.conflict = opts
+	return &DUpsertBulk{create: dcb}
+}
+
+// OnConflictColumns calls `OnConflict` and configures the columns
+// as conflict target.
+func (dcb *DCreateBulk) OnConflictColumns(columns ...string) *DUpsertBulk {
+	dcb.conflict = append(dcb.conflict, sql.ConflictColumns(columns...))
+	return &DUpsertBulk{create: dcb}
+}
+
+// DUpsertBulk is the builder for "upsert"-ing a bulk of Dynamic entities.
+type DUpsertBulk struct {
+	create *DCreateBulk
+}
+
+// UpdateNewValues updates the mutable fields using the new values that
+// were set on create, except the ID field.
+func (u *DUpsertBulk) UpdateNewValues() *DUpsertBulk {
+	u.create.conflict = append(u.create.conflict, sql.ResolveWithNewValues())
+	u.create.conflict = append(u.create.conflict, sql.ResolveWith(func(s *sql.UpdateSet) {
+		for _, b := range u.create.builders {
+			if _, exists := b.mutation.ID(); exists {
+				s.SetIgnore(FieldID)
+				return
+			}
+		}
+	}))
+	return u
+}
+
+// Ignore sets each column to itself in case of conflict.
+func (u *DUpsertBulk) Ignore() *DUpsertBulk {
+	u.create.conflict = append(u.create.conflict, sql.ResolveWithIgnore())
+	return u
+}
+
+// DoNothing configures the conflict_action to `DO NOTHING`.
+// Supported only by SQLite and PostgreSQL.
+func (u *DUpsertBulk) DoNothing() *DUpsertBulk {
+	u.create.conflict = append(u.create.conflict, sql.DoNothing())
+	return u
+}
+
+// Update allows overriding fields `UPDATE` values. See the DCreateBulk.OnConflict
+// documentation for more info.
+func (u *DUpsertBulk) Update(set func(*DUpsert)) *DUpsertBulk {
+	u.create.conflict = append(u.create.conflict, sql.ResolveWith(func(update *sql.UpdateSet) {
+		set(&DUpsert{UpdateSet: update})
+	}))
+	return u
+}
+
+// SetValue sets the value of the given column on conflict.
+func (u *DUpsertBulk) SetValue(column string, v interface{}) *DUpsertBulk {
+	return u.Update(func(s *DUpsert) {
+		s.SetValue(column, v)
+	})
+}
+
+// UpdateValue sets the given column to the value that was provided on create.
+func (u *DUpsertBulk) UpdateValue(column string) *DUpsertBulk {
+	return u.Update(func(s *DUpsert) {
+		s.UpdateValue(column)
+	})
+}
+
+// AddValue adds v to the value of the given column on conflict.
+func (u *DUpsertBulk) AddValue(column string, v interface{}) *DUpsertBulk {
+	return u.Update(func(s *DUpsert) {
+		s.AddValue(column, v)
+	})
+}
+
+// ClearValue sets the given column to NULL on conflict.
+func (u *DUpsertBulk) ClearValue(column string) *DUpsertBulk {
+	return u.Update(func(s *DUpsert) {
+		s.ClearValue(column)
+	})
+}
+
+// Exec executes the query.
+func (u *DUpsertBulk) Exec(ctx context.Context) error {
+	if len(u.create.conflict) == 0 {
+		return errors.New("ent: missing options for DCreateBulk.OnConflict")
+	}
+	return u.create.Exec(ctx)
+}
+
+// ExecX is like Exec, but panics if an error occurs.
+func (u *DUpsertBulk) ExecX(ctx context.Context) {
+	if err := u.Exec(ctx); err != nil {
+		panic(err)
+	}
+}
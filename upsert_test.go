@@ -0,0 +1,62 @@
+package dent
+
+import (
+	"context"
+	"testing"
+
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+)
+
+func TestUpsert(t *testing.T) {
+	ctx := context.Background()
+	table := NewTable("counters")
+	table.AddColumn(&schema.Column{Name: "key", Type: field.TypeString, Unique: true})
+	table.AddColumn(&schema.Column{Name: "hits", Type: field.TypeInt})
+	client := openTest(t, table)
+	counters := client.Table("counters")
+	hits := func(id int) int64 {
+		return counters.Query().Where(ID(id)).OnlyX(ctx).Row["hits"].(int64)
+	}
+	id := counters.Create().SetValue("key", "a").SetValue("hits", 1).SaveX(ctx).ID
+
+	got := counters.Create().SetValue("key", "a").SetValue("hits", 2).
+		OnConflictColumns("key").
+		UpdateNewValues().
+		IDX(ctx)
+	if got != id || hits(id) != 2 {
+		t.Errorf("UpdateNewValues: id %d, hits %d", got, hits(id))
+	}
+	got = counters.Create().SetValue("key", "a").SetValue("hits", 1).
+		OnConflictColumns("key").
+		AddValue("hits", 5).
+		IDX(ctx)
+	if got != id || hits(id) != 7 {
+		t.Errorf("AddValue: id %d, hits %d", got, hits(id))
+	}
+	// The id of skipped inserts is looked up by the conflict columns.
+	got = counters.Create().SetValue("key", "a").SetValue("hits", 0).
+		OnConflictColumns("key").
+		DoNothing().
+		IDX(ctx)
+	if got != id || hits(id) != 7 {
+		t.Errorf("DoNothing: id %d, hits %d", got, hits(id))
+	}
+	if err := counters.Create().SetValue("key", "a").SetValue("hits", 0).OnConflict().Exec(ctx); err == nil {
+		t.Error("expected error of missing conflict options")
+	}
+
+	counters.CreateBulk(
+		counters.Create().SetValue("key", "a").SetValue("hits", 10),
+		counters.Create().SetValue("key", "b").SetValue("hits", 20),
+	).
+		OnConflictColumns("key").
+		UpdateNewValues().
+		ExecX(ctx)
+	if n := counters.Query().CountX(ctx); n != 2 {
+		t.Errorf("unexpected number of rows: %d", n)
+	}
+	if hits(id) != 10 {
+		t.Errorf("bulk UpdateNewValues: hits %d", hits(id))
+	}
+}
@@ -427,3 +427,51 @@ func Not(p Predicate) Predicate {
 		p(s.Not())
 	})
 }
+
+// AggregateEQ applies the EQ predicate on the result of the aggregation function.
+// It is used in the Having step of group-by queries, for example:
+//
+//	client.Table("posts").Query().
+//		GroupBy("user_id").
+//		Aggregate(Count()).
+//		Having(AggregateGT(Count(), 10))
+func AggregateEQ(fn AggregateFunc, v interface{}) Predicate {
+	return Predicate(func(s *sql.Selector) {
+		s.Where(sql.EQ(fn(s), v))
+	})
+}
+
+// AggregateNEQ applies the NEQ predicate on the result of the aggregation function.
+func AggregateNEQ(fn AggregateFunc, v interface{}) Predicate {
+	return Predicate(func(s *sql.Selector) {
+		s.Where(sql.NEQ(fn(s), v))
+	})
+}
+
+// AggregateGT applies the GT predicate on the result of the aggregation function.
+func AggregateGT(fn AggregateFunc, v interface{}) Predicate {
+	return Predicate(func(s *sql.Selector) {
+		s.Where(sql.GT(fn(s), v))
+	})
+}
+
+// AggregateGTE applies the GTE predicate on the result of the aggregation function.
+func AggregateGTE(fn AggregateFunc, v interface{}) Predicate {
+	return Predicate(func(s *sql.Selector) {
+		s.Where(sql.GTE(fn(s), v))
+	})
+}
+
+// AggregateLT applies the LT predicate on the result of the aggregation function.
+func AggregateLT(fn AggregateFunc, v interface{}) Predicate {
+	return Predicate(func(s *sql.Selector) {
+		s.Where(sql.LT(fn(s), v))
+	})
+}
+
+// AggregateLTE applies the LTE predicate on the result of the aggregation function.
+func AggregateLTE(fn AggregateFunc, v interface{}) Predicate {
+	return Predicate(func(s *sql.Selector) {
+		s.Where(sql.LTE(fn(s), v))
+	})
+}
@@ -0,0 +1,321 @@
+package dent
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"entgo.io/ent/dialect/sql"
+)
+
+// Window is a window function, that is evaluated for each row of the query over
+// the rows of its partition. Windows are added to a query with DQuery.Window, and
+// their results are returned in the rows under the name given with As.
+//
+//	client.Table("users").Query().
+//		Window(
+//			Rank().PartitionBy("team").Desc("score").As("rank"),
+//			Over(Sum("score")).PartitionBy("team").Asc("id").As("running_total"),
+//		).
+//		Order(Asc("team", "rank")).
+//		All(ctx)
+type Window struct {
+	fn func(*sql.Selector) string
+	// field of value functions (e.g. LAG), that is
+	// scanned with the type of its column.
+	field     string
+	ranking   bool
+	partition []string
+	order     []windowOrder
+	// orderings that are applied after the ordering
+	// fields (e.g. the ordering of an eager-loaded query).
+	orders []OrderFunc
+	as     string
+}
+
+// windowOrder is an ordering term of a window.
+type windowOrder struct {
+	field string
+	desc  bool
+}
+
+// RowNumber returns the ROW_NUMBER() window function, that numbers the rows
+// of each partition from 1, in the order of the window.
+func RowNumber() *Window {
+	return &Window{fn: func(*sql.Selector) string { return "ROW_NUMBER()" }, ranking: true}
+}
+
+// Rank returns the RANK() window function, that ranks the rows of each partition
+// in the order of the window. Rows with equal values share the same rank, and
+// leave gaps in the sequence.
+func Rank() *Window {
+	return &Window{fn: func(*sql.Selector) string { return "RANK()" }, ranking: true}
+}
+
+// DenseRank returns the DENSE_RANK() window function, that is like Rank, but
+// without gaps in the sequence.
+func DenseRank() *Window {
+	return &Window{fn: func(*sql.Selector) string { return "DENSE_RANK()" }, ranking: true}
+}
+
+// Lag returns the LAG() window function, that returns the value of the field in
+// the row that comes offset rows before the current row in its partition.
+func Lag(field string, offset int) *Window {
+	return &Window{
+		fn: func(s *sql.Selector) string {
+			return "LAG(" + s.C(field) + ", " + strconv.Itoa(offset) + ")"
+		},
+		field: field,
+	}
+}
+
+// Lead returns the LEAD() window function, that returns the value of the field in
+// the row that comes offset rows after the current row in its partition.
+func Lead(field string, offset int) *Window {
+	return &Window{
+		fn: func(s *sql.Selector) string {
+			return "LEAD(" + s.C(field) + ", " + strconv.Itoa(offset) + ")"
+		},
+		field: field,
+	}
+}
+
+// Over returns the given aggregation function as a window function. For example,
+// the running total of a field is the Sum of the field over the rows ordered by ID.
+// The results of aggregation functions are returned as float64 values.
+func Over(fn AggregateFunc) *Window {
+	return &Window{fn: fn}
+}
+
+// PartitionBy divides the rows into groups by the given fields.
+func (w *Window) PartitionBy(fields ...string) *Window {
+	w.partition = append(w.partition, fields...)
+	return w
+}
+
+// Asc orders the rows of each partition by the given fields in ASC order.
+func (w *Window) Asc(fields ...string) *Window {
+	for _, f := range fields {
+		w.order = append(w.order, windowOrder{field: f})
+	}
+	return w
+}
+
+// Desc orders the rows of each partition by the given fields in DESC order.
+func (w *Window) Desc(fields ...string) *Window {
+	for _, f := range fields {
+		w.order = append(w.order, windowOrder{field: f, desc: true})
+	}
+	return w
+}
+
+// As sets the name of the window results in the rows.
+func (w *Window) As(name string) *Window {
+	w.as = name
+	return w
+}
+
+// fields returns the fields that are used by the window.
+func (w *Window) fields() []string {
+	fields := append([]string{}, w.partition...)
+	for _, o := range w.order {
+		fields = append(fields, o.field)
+	}
+	if w.field != "" {
+		fields = append(fields, w.field)
+	}
+	return fields
+}
+
+// expr returns the window expression for the given selector.
+func (w *Window) expr(s *sql.Selector) (string, error) {
+	var b strings.Builder
+	b.WriteString(w.fn(s))
+	b.WriteString(" OVER (")
+	if len(w.partition) > 0 {
+		b.WriteString("PARTITION BY ")
+		b.WriteString(strings.Join(s.Columns(w.partition...), ", "))
+	}
+	order := make([]string, 0, len(w.order))
+	for _, o := range w.order {
+		if o.desc {
+			order = append(order, sql.Desc(s.C(o.field)))
+		} else {
+			order = append(order, s.C(o.field))
+		}
+	}
+	if len(w.orders) > 0 {
+		columns, err := orderColumns(s, w.orders)
+		if err != nil {
+			return "", err
+		}
+		order = append(order, columns...)
+	}
+	if len(order) > 0 {
+		if len(w.partition) > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString("ORDER BY ")
+		b.WriteString(strings.Join(order, ", "))
+	}
+	b.WriteByte(')')
+	return b.String(), nil
+}
+
+// orderColumns returns the order terms of the given orderings, that must order
+// by columns of the table of the selector. Orderings by expressions (e.g. OrderExpr,
+// OrderByJSON or OrderByRelated) are rejected, as they can not be used in windows.
+func orderColumns(s *sql.Selector, orders []OrderFunc) ([]string, error) {
+	ordered := sql.Dialect(s.Dialect()).Select().From(sql.Table(s.TableName()))
+	for _, fn := range orders {
+		fn(ordered)
+	}
+	columns := ordered.OrderColumns()
+	query, args := ordered.Query()
+	// Expressions are not returned by OrderColumns, and are
+	// missing from the query that orders only by the columns.
+	expected, _ := sql.Dialect(s.Dialect()).Select().From(sql.Table(s.TableName())).OrderBy(columns...).Query()
+	if query != expected || len(args) > 0 {
+		return nil, &ValidationError{Name: "order", err: fmt.Errorf("ent: orderings by expressions are not supported in windows (e.g. limits of eager-loaded rows per parent)")}
+	}
+	return columns, nil
+}
+
+// scanValue returns the scan destination of the window results.
+func (w *Window) scanValue(t *Table) (interface{}, error) {
+	switch {
+	case w.ranking:
+		return new(sql.NullInt64), nil
+	case w.field != "":
+		values, err := t.scanValues([]string{w.field})
+		if err != nil {
+			return nil, err
+		}
+		if values[0] == nil {
+			return nil, fmt.Errorf("ent: unexpected field %q for window %q", w.field, w.as)
+		}
+		return values[0], nil
+	default:
+		return new(sql.NullFloat64), nil
+	}
+}
+
+// Window adds the given window functions to the selection of the query. Windows are
+// evaluated over the rows that match the predicates of the query, and their results
+// can be used in ordering, selections and the predicates that are given to Qualify.
+func (dq *DQuery) Window(ws ...*Window) *DQuery {
+	dq.windows = append(dq.windows, ws...)
+	return dq
+}
+
+// Qualify adds predicates that are applied on the rows of the query after the
+// window functions are evaluated. Unlike Where, they can filter on the results
+// of the window functions.
+func (dq *DQuery) Qualify(ps ...Predicate) *DQuery {
+	dq.qualify = append(dq.qualify, ps...)
+	return dq
+}
+
+// TopN keeps the first n rows of each partition of the given window, where
+// the window is added to the selection of the query. For example, the 3 most
+// liked posts of each user:
+//
+//	client.Table("posts").Query().
+//		TopN(3, RowNumber().PartitionBy("user_id").Desc("likes").As("pos")).
+//		Order(Asc("user_id", "pos")).
+//		All(ctx)
+//
+// Ranking functions with ties, like Rank, may return more than n rows of a partition.
+func (dq *DQuery) TopN(n int, w *Window) *DQuery {
+	return dq.Window(w).Qualify(func(s *sql.Selector) {
+		s.Where(sql.LTE(s.C(w.as), n))
+	})
+}
+
+// window returns the window of the query with the given name.
+func (dq *DQuery) window(name string) (*Window, bool) {
+	for _, w := range dq.windows {
+		if w.as == name {
+			return w, true
+		}
+	}
+	return nil, false
+}
+
+// outerPredicates returns the predicates that are applied on the selector of the
+// query. With window functions, the predicates of the query are applied before
+// the functions are evaluated, and only the qualify predicates are left.
+func (dq *DQuery) outerPredicates() []Predicate {
+	if len(dq.windows) > 0 {
+		return dq.qualify
+	}
+	return append(dq.predicates[:len(dq.predicates):len(dq.predicates)], dq.qualify...)
+}
+
+// filter adds predicates that are applied on the final rows of the query.
+func (dq *DQuery) filter(ps ...Predicate) {
+	if len(dq.windows) > 0 {
+		dq.qualify = append(dq.qualify, ps...)
+	} else {
+		dq.predicates = append(dq.predicates, ps...)
+	}
+}
+
+// scanValues returns the types for scanning values from sql.Rows,
+// including the results of the window functions.
+func (dq *DQuery) scanValues(columns []string) ([]interface{}, error) {
+	values, err := dq.table.scanValues(columns)
+	if err != nil {
+		return nil, err
+	}
+	for i, c := range columns {
+		if values[i] != nil {
+			continue
+		}
+		if w, ok := dq.window(c); ok {
+			if values[i], err = w.scanValue(dq.table); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return values, nil
+}
+
+// windowQuery returns the selector of the window functions. The functions are
+// evaluated in a common table expression that selects the rows matching the
+// predicates of the query, to make their results addressable by name.
+//
+//	WITH `users_window` AS (SELECT `users`.`id`, ..., RANK() OVER (...) AS `rank` FROM `users` WHERE ...)
+//	SELECT ... FROM `users_window`
+func (dq *DQuery) windowQuery(ctx context.Context, from *sql.Selector) (*sql.Selector, error) {
+	builder := sql.Dialect(dq.table.driver.Dialect())
+	if from == nil {
+		from = builder.Select().From(builder.Table(dq.table.Name))
+	}
+	// The default columns of the query end with the window names.
+	columns := dq.columns()
+	columns = from.Columns(columns[:len(columns)-len(dq.windows)]...)
+	for _, p := range dq.predicates {
+		p(from)
+	}
+	for _, w := range dq.windows {
+		expr, err := w.expr(from)
+		if err != nil {
+			return nil, err
+		}
+		if w.as == "" {
+			return nil, &ValidationError{Name: "window", err: fmt.Errorf("ent: missing name for window %s", expr)}
+		}
+		for _, f := range w.fields() {
+			if _, ok := dq.window(f); ok || !dq.hasColumn(f) {
+				return nil, &ValidationError{Name: f, err: fmt.Errorf("ent: invalid field %q for window %q", f, w.as)}
+			}
+		}
+		columns = append(columns, sql.As(expr, w.as))
+	}
+	from.Select(columns...)
+	with := sql.With(dq.table.Name + "_window").As(from)
+	ctx = withCTETable(ctx, with.Name(), dq.table.Name)
+	return builder.Select().From(builder.Table(with.Name())).Prefix(with).WithContext(ctx), nil
+}
@@ -0,0 +1,79 @@
+package dent
+
+import (
+	"context"
+	"testing"
+
+	"entgo.io/ent/dialect/sql/schema"
+	"entgo.io/ent/schema/field"
+)
+
+func windowTable() *schema.Table {
+	table := NewTable("scores")
+	table.AddColumn(&schema.Column{Name: "team", Type: field.TypeString})
+	table.AddColumn(&schema.Column{Name: "score", Type: field.TypeInt})
+	return table
+}
+
+func TestWindow(t *testing.T) {
+	ctx := context.Background()
+	client := openTest(t, windowTable())
+	scores := client.Table("scores")
+	for _, s := range []struct {
+		team  string
+		score int
+	}{{"a", 10}, {"a", 30}, {"a", 30}, {"b", 20}, {"b", 5}} {
+		scores.Create().SetValue("team", s.team).SetValue("score", s.score).SaveX(ctx)
+	}
+	nodes, err := scores.Query().
+		Where(IntGT("score", 5)).
+		Window(
+			RowNumber().PartitionBy("team").Desc("score").Asc(FieldID).As("pos"),
+			Rank().PartitionBy("team").Desc("score").As("rank"),
+			Over(Sum("score")).PartitionBy("team").Asc(FieldID).As("total"),
+			Lag("score", 1).PartitionBy("team").Asc(FieldID).As("prev"),
+		).
+		Order(Asc("team", "pos")).
+		All(ctx)
+	if err != nil {
+		t.Fatalf("failed querying windows: %v", err)
+	}
+	want := []struct {
+		id, pos, rank int64
+		total         float64
+	}{{2, 1, 1, 40}, {3, 2, 1, 70}, {1, 3, 3, 10}, {4, 1, 1, 20}}
+	if len(nodes) != len(want) {
+		t.Fatalf("unexpected rows: %v", nodes)
+	}
+	for i, w := range want {
+		row := nodes[i].Row
+		if int64(nodes[i].ID) != w.id || row["pos"] != w.pos || row["rank"] != w.rank || row["total"] != w.total {
+			t.Errorf("row %d: unexpected window values: %d %v", i, nodes[i].ID, row)
+		}
+	}
+	if nodes[2].Row["prev"] != int64(0) || nodes[1].Row["prev"] != int64(30) {
+		t.Errorf("unexpected lag values: %v, %v", nodes[2].Row, nodes[1].Row)
+	}
+
+	// The best score of each team.
+	ids := scores.Query().
+		TopN(1, RowNumber().PartitionBy("team").Desc("score").Asc(FieldID).As("pos")).
+		Order(Asc(FieldID)).
+		IDsX(ctx)
+	if !equalInts(ids, []int{2, 4}) {
+		t.Errorf("unexpected top scores: %v", ids)
+	}
+	n := scores.Query().
+		Window(Rank().PartitionBy("team").Desc("score").As("rank")).
+		Qualify(IntEQ("rank", 1)).
+		CountX(ctx)
+	if n != 3 {
+		t.Errorf("unexpected count of first ranks: %d", n)
+	}
+	if _, err := scores.Query().Window(RowNumber()).All(ctx); !IsValidationError(err) {
+		t.Errorf("expected validation error of unnamed window, got: %v", err)
+	}
+	if _, err := scores.Query().Window(RowNumber().Asc("nope").As("pos")).All(ctx); !IsValidationError(err) {
+		t.Errorf("expected validation error of unknown field, got: %v", err)
+	}
+}